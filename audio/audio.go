@@ -0,0 +1,182 @@
+// Package audio plays the background music and the sound effects. The music
+// loops without gaps, it can be paused and faded in and out and it has its
+// own volume, separate from the sound effects. Which sound library is used
+// depends on the same build tags as the sound in the prototype/draw package:
+// on Windows the gonutz/mixer is used by default, with the sdl2 tag SDL2_mixer
+// is used and on other platforms the sounds are streamed to aplay.
+package audio
+
+import (
+	"io"
+	"time"
+)
+
+// backend is the sound library that actually plays the sounds. The files are
+// passed as the complete WAV file data, the backends cache the decoded
+// sounds by their path.
+type backend interface {
+	playMusic(data []byte) error
+	setMusicVolume(v float64)
+	setMusicPaused(paused bool)
+	playSound(path string, data []byte, volume, pan float64) error
+	// update is called once per frame
+	update()
+	close()
+}
+
+// Player plays the music and the sound effects. All volumes are between 0
+// (silent) and 1 (full volume).
+type Player struct {
+	backend backend
+	open    func(path string) (io.ReadCloser, error)
+	files   map[string][]byte
+
+	musicVolume   float64
+	effectsVolume float64
+	// fade is the level that the music is faded to, it changes towards
+	// fadeTarget by fadeSpeed per second
+	fade, fadeTarget, fadeSpeed float64
+	// playedVolume is the music volume that the backend plays at
+	playedVolume float64
+	lastUpdate   time.Time
+}
+
+// Open prepares the sound library. It has to be called after the game window
+// was created. The sound files are read with the given open function, e.g.
+// draw.OpenFile.
+func Open(open func(path string) (io.ReadCloser, error)) (*Player, error) {
+	b, err := newBackend()
+	if err != nil {
+		return nil, err
+	}
+	return &Player{
+		backend:       b,
+		open:          open,
+		files:         make(map[string][]byte),
+		musicVolume:   1,
+		effectsVolume: 1,
+		fade:          1,
+		fadeTarget:    1,
+		playedVolume:  -1,
+	}, nil
+}
+
+func (p *Player) read(path string) ([]byte, error) {
+	if data, ok := p.files[path]; ok {
+		return data, nil
+	}
+	f, err := p.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	p.files[path] = data
+	return data, nil
+}
+
+// PlayMusic starts looping the WAV file at the given path, replacing the music
+// that was playing.
+func (p *Player) PlayMusic(path string) error {
+	data, err := p.read(path)
+	if err != nil {
+		return err
+	}
+	if err := p.backend.playMusic(data); err != nil {
+		return err
+	}
+	p.playedVolume = -1
+	p.Update()
+	return nil
+}
+
+// SetMusicPaused pauses or resumes the music where it stopped.
+func (p *Player) SetMusicPaused(paused bool) {
+	p.backend.setMusicPaused(paused)
+}
+
+// FadeMusic changes the level of the music to the given fraction of the
+// music volume, taking the given time to get there from silence to full
+// level or back. Call FadeMusic(1, ...) to fade back in.
+func (p *Player) FadeMusic(level float64, d time.Duration) {
+	p.fadeTarget = clamp(level)
+	if d <= 0 {
+		p.fade = p.fadeTarget
+		return
+	}
+	p.fadeSpeed = 1 / d.Seconds()
+}
+
+// SetMusicVolume changes the volume of the music.
+func (p *Player) SetMusicVolume(v float64) {
+	p.musicVolume = clamp(v)
+}
+
+// SetEffectsVolume changes the volume of the sound effects that are played
+// from now on.
+func (p *Player) SetEffectsVolume(v float64) {
+	p.effectsVolume = clamp(v)
+}
+
+// PlaySound plays the WAV file at the given path once, over the music. The
+// volume is relative to the effects volume. Pan goes from -1, where only the
+// left speaker plays the sound, over 0, where both speakers play it at full
+// volume, to 1 for only the right speaker.
+func (p *Player) PlaySound(path string, volume, pan float64) error {
+	data, err := p.read(path)
+	if err != nil {
+		return err
+	}
+	return p.backend.playSound(path, data, p.effectsVolume*clamp(volume), max(-1, min(pan, 1)))
+}
+
+// panVolumes returns the volumes of the left and right speaker for a pan
+// between -1 and 1, see Player.PlaySound.
+func panVolumes(pan float64) (left, right float64) {
+	return min(1, 1-pan), min(1, 1+pan)
+}
+
+// Update advances the fading of the music, call it once per frame.
+func (p *Player) Update() {
+	now := time.Now()
+	if !p.lastUpdate.IsZero() && p.fade != p.fadeTarget {
+		step := p.fadeSpeed * now.Sub(p.lastUpdate).Seconds()
+		if p.fade < p.fadeTarget {
+			p.fade = min(p.fade+step, p.fadeTarget)
+		} else {
+			p.fade = max(p.fade-step, p.fadeTarget)
+		}
+	}
+	p.lastUpdate = now
+	if v := p.musicVolume * p.fade; v != p.playedVolume {
+		p.backend.setMusicVolume(v)
+		p.playedVolume = v
+	}
+	p.backend.update()
+}
+
+// Close stops the music.
+func (p *Player) Close() {
+	p.backend.close()
+}
+
+func clamp(v float64) float64 {
+	return max(0, min(v, 1))
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,154 @@
+//go:build !windows && !sdl2
+
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/gonutz/mixer/wav"
+)
+
+// aplayArgs make aplay read raw samples, in the format that pcm returns, from
+// its standard input. The short buffer of 0.1 s lets changes to the music's
+// volume be heard right away.
+var aplayArgs = []string{"-q", "-t", "raw", "-f", "S16_LE", "-c", "2", "-r", "44100", "-B", "100000"}
+
+type aplayBackend struct {
+	// sounds are the samples of the sound effects by their paths
+	sounds map[string][]byte
+	music  *exec.Cmd
+	// musicIn is where the music samples are streamed to aplay
+	musicIn io.WriteCloser
+
+	// mu guards the music settings that the streaming goroutine reads
+	mu          sync.Mutex
+	musicVolume float64
+	musicPaused bool
+}
+
+func newBackend() (backend, error) {
+	if _, err := exec.LookPath("aplay"); err != nil {
+		return nil, err
+	}
+	return &aplayBackend{sounds: make(map[string][]byte), musicVolume: 1}, nil
+}
+
+// pcm decodes a WAV file to 16 bit stereo samples at 44100 Hz.
+func pcm(data []byte) ([]byte, error) {
+	w, err := wav.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	samples := wav.ConvertTo44100Hz2Channels16BitSamples(w).Data
+	return samples[:len(samples)-len(samples)%4], nil
+}
+
+// scale writes the stereo samples from src to dst with the given volumes for
+// the left and right channel.
+func scale(dst, src []byte, left, right float64) {
+	for i := 0; i+3 < len(src); i += 4 {
+		l := float64(int16(binary.LittleEndian.Uint16(src[i:])))
+		r := float64(int16(binary.LittleEndian.Uint16(src[i+2:])))
+		binary.LittleEndian.PutUint16(dst[i:], uint16(int16(l*left)))
+		binary.LittleEndian.PutUint16(dst[i+2:], uint16(int16(r*right)))
+	}
+}
+
+func (a *aplayBackend) playMusic(data []byte) error {
+	samples, err := pcm(data)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	cmd := exec.Command("aplay", aplayArgs...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	a.close()
+	a.music, a.musicIn = cmd, in
+	go a.stream(cmd, in, samples)
+	return nil
+}
+
+// stream writes the music to aplay over and over in short chunks, the writes
+// block while aplay's buffer is full. While paused, silence is written so aplay
+// keeps the sound device. It stops when aplay is closed.
+func (a *aplayBackend) stream(cmd *exec.Cmd, in io.Writer, samples []byte) {
+	defer cmd.Wait()
+	buf := make([]byte, 44100/50*4)
+	silence := make([]byte, len(buf))
+	pos := 0
+	for {
+		a.mu.Lock()
+		volume, paused := a.musicVolume, a.musicPaused
+		a.mu.Unlock()
+		chunk := silence
+		if !paused {
+			for n := 0; n < len(buf); {
+				c := copy(buf[n:], samples[pos:])
+				scale(buf[n:n+c], buf[n:n+c], volume, volume)
+				n += c
+				pos = (pos + c) % len(samples)
+			}
+			chunk = buf
+		}
+		if _, err := in.Write(chunk); err != nil {
+			return
+		}
+	}
+}
+
+func (a *aplayBackend) setMusicVolume(v float64) {
+	a.mu.Lock()
+	a.musicVolume = v
+	a.mu.Unlock()
+}
+
+func (a *aplayBackend) setMusicPaused(paused bool) {
+	a.mu.Lock()
+	a.musicPaused = paused
+	a.mu.Unlock()
+}
+
+// playSound starts a new aplay for every sound, so they can overlap.
+func (a *aplayBackend) playSound(path string, data []byte, volume, pan float64) error {
+	samples, ok := a.sounds[path]
+	if !ok {
+		var err error
+		samples, err = pcm(data)
+		if err != nil {
+			return err
+		}
+		a.sounds[path] = samples
+	}
+	left, right := panVolumes(pan)
+	scaled := make([]byte, len(samples))
+	scale(scaled, samples, volume*left, volume*right)
+	cmd := exec.Command("aplay", aplayArgs...)
+	cmd.Stdin = bytes.NewReader(scaled)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+func (a *aplayBackend) update() {}
+
+func (a *aplayBackend) close() {
+	if a.music != nil {
+		a.musicIn.Close()
+		a.music.Process.Kill()
+		a.music, a.musicIn = nil, nil
+	}
+}
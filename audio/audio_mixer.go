@@ -0,0 +1,124 @@
+//go:build windows && !sdl2
+
+package audio
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/gonutz/mixer"
+	"github.com/gonutz/mixer/wav"
+)
+
+type mixerBackend struct {
+	sources map[string]mixer.SoundSource
+	// music plays the song twice in a row, when it gets into the second time,
+	// it is set back by one song length, which continues at the exact same
+	// sample so there is no gap
+	music       mixer.Sound
+	musicSource mixer.SoundSource
+	musicLength time.Duration
+	// the mixer forgets a sound that played to its end, these are kept to
+	// start it again the same way
+	musicVolume float32
+	musicPaused bool
+}
+
+// newBackend starts the mixer, the draw window has usually done that already.
+func newBackend() (backend, error) {
+	if err := mixer.Init(); err != nil {
+		return nil, err
+	}
+	return &mixerBackend{sources: make(map[string]mixer.SoundSource)}, nil
+}
+
+func (m *mixerBackend) playMusic(data []byte) error {
+	w, err := wav.Read(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	twice := *w
+	twice.Data = append(append([]byte{}, w.Data...), w.Data...)
+	source, err := mixer.NewSoundSource(&twice)
+	if err != nil {
+		return err
+	}
+	m.close()
+	m.musicSource = source
+	m.musicLength = source.Length() / 2
+	m.startMusic()
+	return nil
+}
+
+// startMusic plays the music source from the start, with the current volume.
+func (m *mixerBackend) startMusic() {
+	m.music = m.musicSource.PlayPaused()
+	m.music.SetVolume(m.musicVolume)
+	m.music.SetPaused(m.musicPaused)
+}
+
+func (m *mixerBackend) setMusicVolume(v float64) {
+	m.musicVolume = float32(v)
+	if m.music != nil {
+		m.music.SetVolume(m.musicVolume)
+	}
+}
+
+func (m *mixerBackend) setMusicPaused(paused bool) {
+	m.musicPaused = paused
+	if m.music != nil {
+		m.music.SetPaused(paused)
+	}
+}
+
+func (m *mixerBackend) playSound(path string, data []byte, volume, pan float64) error {
+	source, ok := m.sources[path]
+	if !ok {
+		w, err := wav.Read(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		source, err = mixer.NewSoundSource(w)
+		if err != nil {
+			return err
+		}
+		m.sources[path] = source
+	}
+	// start it paused so it does not play at the wrong volume for a moment,
+	// the mixer pans the same way as PlaySound
+	s := source.PlayPaused()
+	s.SetVolume(float32(volume))
+	s.SetPan(float32(pan))
+	s.SetPaused(false)
+	return nil
+}
+
+func (m *mixerBackend) update() {
+	if m.music == nil {
+		return
+	}
+	if m.music.Stopped() {
+		// the frames stalled for longer than a song length, e.g. while the
+		// window was dragged, so the sound played to its end, the music
+		// starts over with a short gap
+		m.startMusic()
+		return
+	}
+	// the mixer goroutine moves the position while it is set back, this is
+	// done while the music is paused so the mixer does not move it at the
+	// same time and the jump is exactly one song length
+	if m.music.Position() >= m.musicLength {
+		m.music.SetPaused(true)
+		m.music.SetPosition(m.music.Position() - m.musicLength)
+		m.music.SetPaused(m.musicPaused)
+	}
+}
+
+// close stops the music, the mixer itself is closed by the draw window.
+func (m *mixerBackend) close() {
+	if m.music != nil {
+		m.music.SetPaused(true)
+		m.music = nil
+	}
+	m.musicSource = nil
+}
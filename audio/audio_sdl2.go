@@ -0,0 +1,89 @@
+//go:build sdl2
+
+package audio
+
+import (
+	"errors"
+
+	"github.com/gonutz/go-sdl2/mix"
+	"github.com/gonutz/go-sdl2/sdl"
+)
+
+type sdlBackend struct {
+	chunks map[string]*mix.Chunk
+	music  *mix.Music
+	// musicData is streamed by SDL while the music plays, it must be kept
+	musicData []byte
+}
+
+// newBackend uses the audio device that the draw window opened.
+func newBackend() (backend, error) {
+	if _, _, _, open, _ := mix.QuerySpec(); open == 0 {
+		return nil, errors.New("audio: the audio device is not open")
+	}
+	return &sdlBackend{chunks: make(map[string]*mix.Chunk)}, nil
+}
+
+func (s *sdlBackend) playMusic(data []byte) error {
+	rw, err := sdl.RWFromMem(data)
+	if err != nil {
+		return err
+	}
+	music, err := mix.LoadMUSRW(rw, 1)
+	if err != nil {
+		return err
+	}
+	s.close()
+	s.music, s.musicData = music, data
+	// -1 loops forever
+	return music.Play(-1)
+}
+
+func (s *sdlBackend) setMusicVolume(v float64) {
+	mix.VolumeMusic(int(v * mix.MAX_VOLUME))
+}
+
+func (s *sdlBackend) setMusicPaused(paused bool) {
+	if paused {
+		mix.PauseMusic()
+	} else {
+		mix.ResumeMusic()
+	}
+}
+
+func (s *sdlBackend) playSound(path string, data []byte, volume, pan float64) error {
+	chunk, ok := s.chunks[path]
+	if !ok {
+		rw, err := sdl.RWFromMem(data)
+		if err != nil {
+			return err
+		}
+		chunk, err = mix.LoadWAVRW(rw, true)
+		if err != nil {
+			return err
+		}
+		s.chunks[path] = chunk
+	}
+	channel, err := chunk.Play(-1, 0)
+	if err != nil {
+		return err
+	}
+	mix.Volume(channel, int(volume*mix.MAX_VOLUME))
+	left, right := panVolumes(pan)
+	return mix.SetPanning(channel, uint8(left*255), uint8(right*255))
+}
+
+func (s *sdlBackend) update() {}
+
+func (s *sdlBackend) close() {
+	if s.music == nil {
+		return
+	}
+	// the draw window closes the audio device when it is closed, after that
+	// the music is already gone
+	if _, _, _, open, _ := mix.QuerySpec(); open != 0 {
+		mix.HaltMusic()
+		s.music.Free()
+	}
+	s.music, s.musicData = nil, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"image"
+	_ "image/png"
+	"math"
+
+	"github.com/gonutz/jolina/draw"
+)
+
+// camera is a draw.Window that shows a field of a fixed logical size in a
+// window of any size. The field is scaled to fill as much of the window as
+// possible without distorting it, the rest of the window is left black. All
+// coordinates going in and out of the camera are logical coordinates.
+type camera struct {
+	draw.Window
+	w, h       int
+	scale      float64
+	offX, offY int
+}
+
+func newCamera(window draw.Window, w, h int) *camera {
+	windowW, windowH := window.Size()
+	scale := math.Min(float64(windowW)/float64(w), float64(windowH)/float64(h))
+	return &camera{
+		Window: window,
+		w:      w,
+		h:      h,
+		scale:  scale,
+		offX:   (windowW - int(float64(w)*scale)) / 2,
+		offY:   (windowH - int(float64(h)*scale)) / 2,
+	}
+}
+
+// drawBars blacks out everything outside the field, call it after drawing.
+func (c *camera) drawBars() {
+	windowW, windowH := c.Window.Size()
+	right, bottom := c.toWindow(c.w, c.h)
+	c.Window.FillRect(0, 0, c.offX, windowH, draw.Black)
+	c.Window.FillRect(right, 0, windowW-right, windowH, draw.Black)
+	c.Window.FillRect(0, 0, windowW, c.offY, draw.Black)
+	c.Window.FillRect(0, bottom, windowW, windowH-bottom, draw.Black)
+}
+
+func (c *camera) toWindow(x, y int) (int, int) {
+	return c.offX + int(math.Round(float64(x)*c.scale)),
+		c.offY + int(math.Round(float64(y)*c.scale))
+}
+
+// toWindowRect transforms both corners of the rectangle so neighboring
+// rectangles still touch after rounding.
+func (c *camera) toWindowRect(x, y, w, h int) (int, int, int, int) {
+	x0, y0 := c.toWindow(x, y)
+	x1, y1 := c.toWindow(x+w, y+h)
+	return x0, y0, x1 - x0, y1 - y0
+}
+
+func (c *camera) fromWindow(x, y int) (int, int) {
+	return int(math.Floor(float64(x-c.offX) / c.scale)),
+		int(math.Floor(float64(y-c.offY) / c.scale))
+}
+
+func (c *camera) Size() (int, int) {
+	return c.w, c.h
+}
+
+func (c *camera) MousePosition() (int, int) {
+	return c.fromWindow(c.Window.MousePosition())
+}
+
+func (c *camera) Clicks() []draw.MouseClick {
+	clicks := c.Window.Clicks()
+	for i := range clicks {
+		clicks[i].X, clicks[i].Y = c.fromWindow(clicks[i].X, clicks[i].Y)
+	}
+	return clicks
+}
+
+func (c *camera) DrawPoint(x, y int, color draw.Color) {
+	c.FillRect(x, y, 1, 1, color)
+}
+
+func (c *camera) DrawLine(fromX, fromY, toX, toY int, color draw.Color) {
+	fromX, fromY = c.toWindow(fromX, fromY)
+	toX, toY = c.toWindow(toX, toY)
+	c.Window.DrawLine(fromX, fromY, toX, toY, color)
+}
+
+func (c *camera) DrawRect(x, y, w, h int, color draw.Color) {
+	x, y, w, h = c.toWindowRect(x, y, w, h)
+	c.Window.DrawRect(x, y, w, h, color)
+}
+
+func (c *camera) FillRect(x, y, w, h int, color draw.Color) {
+	x, y, w, h = c.toWindowRect(x, y, w, h)
+	c.Window.FillRect(x, y, w, h, color)
+}
+
+func (c *camera) DrawEllipse(x, y, w, h int, color draw.Color) {
+	x, y, w, h = c.toWindowRect(x, y, w, h)
+	c.Window.DrawEllipse(x, y, w, h, color)
+}
+
+func (c *camera) FillEllipse(x, y, w, h int, color draw.Color) {
+	x, y, w, h = c.toWindowRect(x, y, w, h)
+	c.Window.FillEllipse(x, y, w, h, color)
+}
+
+func (c *camera) DrawImageFile(path string, x, y int) error {
+	return c.DrawImageFileRotated(path, x, y, 0)
+}
+
+func (c *camera) DrawImageFileTo(path string, x, y, w, h, rotationCWDeg int) error {
+	x, y, w, h = c.toWindowRect(x, y, w, h)
+	return c.Window.DrawImageFileTo(path, x, y, w, h, rotationCWDeg)
+}
+
+func (c *camera) DrawImageFileRotated(path string, x, y, rotationCWDeg int) error {
+	w, h, err := imageSize(path)
+	if err != nil {
+		return err
+	}
+	return c.DrawImageFileTo(path, x, y, w, h, rotationCWDeg)
+}
+
+func (c *camera) DrawImageFilePart(
+	path string,
+	sourceX, sourceY, sourceWidth, sourceHeight int,
+	destX, destY, destWidth, destHeight int,
+	rotationCWDeg int,
+) error {
+	destX, destY, destWidth, destHeight = c.toWindowRect(destX, destY, destWidth, destHeight)
+	return c.Window.DrawImageFilePart(
+		path,
+		sourceX, sourceY, sourceWidth, sourceHeight,
+		destX, destY, destWidth, destHeight,
+		rotationCWDeg,
+	)
+}
+
+func (c *camera) DrawText(text string, x, y int, color draw.Color) {
+	c.DrawScaledText(text, x, y, 1, color)
+}
+
+func (c *camera) DrawScaledText(text string, x, y int, scale float32, color draw.Color) {
+	x, y = c.toWindow(x, y)
+	c.Window.DrawScaledText(text, x, y, scale*float32(c.scale), color)
+}
+
+// imageSizes caches the sizes of the images that were drawn, see imageSize.
+var imageSizes = map[string]image.Point{}
+
+// imageSize returns the size of an image file in pixels. The draw package
+// does not tell us, so we read the image header ourselves.
+func imageSize(path string) (w, h int, err error) {
+	if size, ok := imageSizes[path]; ok {
+		return size.X, size.Y, nil
+	}
+	f, err := draw.OpenFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	imageSizes[path] = image.Pt(config.Width, config.Height)
+	return config.Width, config.Height, nil
+}
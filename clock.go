@@ -0,0 +1,40 @@
+package main
+
+import "time"
+
+// The game logic runs at a fixed rate of 60 ticks per second, no matter how
+// often the monitor refreshes. All times in the settings are counted in these
+// ticks, the README calls them frames.
+const tickTime = time.Second / 60
+
+// maxTicksPerFrame limits how far the game catches up after a slow frame, e.g.
+// while the window is dragged. After longer breaks the game slows down instead
+// of jumping ahead.
+const maxTicksPerFrame = 5
+
+// clock measures the time between the frames that the window shows and tells
+// how many ticks the game has to advance to keep up with the real time.
+type clock struct {
+	last time.Time
+	// behind is the time that passed but was not used up by ticks yet
+	behind time.Duration
+}
+
+// frame is called once per frame with the current time. It returns the number
+// of ticks to run in this frame and how far the time is between the last and
+// the next tick, from 0 to 1, for drawing the kiwis and the ball in between.
+func (c *clock) frame(now time.Time) (ticks int, alpha float64) {
+	if c.last.IsZero() {
+		c.last = now
+		return 1, 1
+	}
+	c.behind += now.Sub(c.last)
+	c.last = now
+	ticks = int(c.behind / tickTime)
+	if ticks > maxTicksPerFrame {
+		ticks = maxTicksPerFrame
+		c.behind = maxTicksPerFrame * tickTime
+	}
+	c.behind -= time.Duration(ticks) * tickTime
+	return ticks, float64(c.behind) / float64(tickTime)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gonutz/jolina/game"
+)
+
+// settings are the tuning values that can be changed in a JSON config file
+// and overwritten with command line flags. The rules are in game.Config, the
+// other values only change how the game looks.
+type settings struct {
+	game.Config
+	BlinkCooldown int `json:"blinkCooldown"`
+	// MusicVolume and EffectsVolume are in percent.
+	MusicVolume   int `json:"musicVolume"`
+	EffectsVolume int `json:"effectsVolume"`
+}
+
+func defaultSettings() settings {
+	return settings{
+		Config:        game.DefaultConfig(),
+		BlinkCooldown: 30,
+		MusicVolume:   80,
+		EffectsVolume: 100,
+	}
+}
+
+// setting connects a settings value to its JSON name.
+type setting struct {
+	name  string
+	value *int
+	usage string
+}
+
+func (s *settings) list() []setting {
+	return []setting{
+		{"players", &s.Players, "number of kiwis, 2 or 4 to play two against two"},
+		{"kiwiSpeed", &s.KiwiSpeed, "walking speed of the kiwis in pixels per frame"},
+		{"shootFrames", &s.ShootFrames, "number of frames that a kick is shown"},
+		{"shootCooldown", &s.ShootCooldown, "number of frames after a kick before a kiwi can move again"},
+		{"minBallShootSpeed", &s.MinBallShootSpeed, "slowest kick in pixels per frame"},
+		{"maxBallShootSpeed", &s.MaxBallShootSpeed, "fastest kick in pixels per frame"},
+		{"chargeFrames", &s.ChargeFrames, "number of frames that the kick button is held for the fastest kick, 0 for random kicks without charging"},
+		{"kickVariation", &s.KickVariation, "most that the speed of a charged kick randomly differs, in pixels per frame"},
+		{"ballFriction", &s.BallFriction, "how much the ball rolling on the ground slows down every frame"},
+		{"gravity", &s.Gravity, "how much faster the ball falls every frame"},
+		{"bounceRestitution", &s.BounceRestitution, "percentage of its speed that the ball keeps when bouncing"},
+		{"maxKickAngle", &s.MaxKickAngle, "steepest angle in degrees that the ball can be kicked at, 0 for flat kicks"},
+		{"goalWidth", &s.GoalWidth, "how far the goals reach into the field, 0 makes the whole end of the field the goal"},
+		{"goalHeight", &s.GoalHeight, "height of the crossbars"},
+		{"keeperZone", &s.KeeperZone, "width of the zone in front of each goal that only its goalkeeper may enter"},
+		{"halfFrames", &s.HalfFrames, "length of a half in a timed match, 0 to play up to winScore goals instead"},
+		{"winScore", &s.WinScore, "number of goals to win a match"},
+		{"winSoundCooldown", &s.WinSoundCooldown, "number of frames between the last goal and the win sound"},
+		{"blinkCooldown", &s.BlinkCooldown, "number of frames that the restart text blinks on and off"},
+		{"musicVolume", &s.MusicVolume, "volume of the music in percent"},
+		{"effectsVolume", &s.EffectsVolume, "volume of the sound effects in percent"},
+	}
+}
+
+func (s *settings) validate() error {
+	if err := s.Config.Validate(); err != nil {
+		return err
+	}
+	if s.BlinkCooldown < 1 {
+		return fmt.Errorf("blinkCooldown is %d but must be at least 1", s.BlinkCooldown)
+	}
+	if s.MusicVolume < 0 || s.MusicVolume > 100 {
+		return fmt.Errorf("musicVolume is %d but must be between 0 and 100", s.MusicVolume)
+	}
+	if s.EffectsVolume < 0 || s.EffectsVolume > 100 {
+		return fmt.Errorf("effectsVolume is %d but must be between 0 and 100", s.EffectsVolume)
+	}
+	return nil
+}
+
+// settingFlags registers a command line flag for every setting. After the
+// flags are parsed, call apply to overwrite the settings from the config file
+// with the flags that were actually given.
+func settingFlags() (apply func(*settings)) {
+	var fromFlags settings
+	defaults := defaultSettings()
+	list := fromFlags.list()
+	for i, s := range defaults.list() {
+		flag.IntVar(list[i].value, s.name, *s.value, s.usage)
+	}
+	return func(to *settings) {
+		target := to.list()
+		flag.Visit(func(f *flag.Flag) {
+			for i := range list {
+				if list[i].name == f.Name {
+					*target[i].value = *list[i].value
+				}
+			}
+		})
+	}
+}
+
+// defaultConfigPath is the per-user config file that is loaded if no other
+// file is given on the command line.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "jolina", "config.json")
+}
+
+// loadSettings reads the JSON config file at path. If path is the default
+// config path and the file does not exist, the default settings are used.
+func loadSettings(path string) (settings, error) {
+	s := defaultSettings()
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && path == defaultConfigPath() {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return s, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := s.validate(); err != nil {
+		return s, fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
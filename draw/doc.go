@@ -0,0 +1,6 @@
+// Package draw is the part of the prototype/draw package that the game uses.
+// Normally it is the prototype/draw package itself. With the headless build
+// tag it only has copies of its types and RunWindow fails, so the game builds
+// without the system libraries that a window needs and can be tested with the
+// offscreen package.
+package draw
@@ -0,0 +1,184 @@
+//go:build !headless
+
+package draw
+
+import (
+	"io"
+	"os"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// The types are the prototype/draw package's.
+type (
+	Window         = draw.Window
+	UpdateFunction = draw.UpdateFunction
+	Color          = draw.Color
+	MouseButton    = draw.MouseButton
+	MouseClick     = draw.MouseClick
+	Key            = draw.Key
+)
+
+// OpenFile reads the image and sound files, it defaults to os.Open.
+var OpenFile = func(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func init() {
+	// the window reads its files with draw.OpenFile
+	draw.OpenFile = func(path string) (io.ReadCloser, error) {
+		return OpenFile(path)
+	}
+}
+
+// RunWindow opens the window and calls update 60 times per second until the
+// window is closed.
+func RunWindow(title string, width, height int, update UpdateFunction) error {
+	return draw.RunWindow(title, width, height, update)
+}
+
+// RGB creates an opaque color.
+func RGB(r, g, b float32) Color {
+	return draw.RGB(r, g, b)
+}
+
+// RGBA creates a color with the opacity a.
+func RGBA(r, g, b, a float32) Color {
+	return draw.RGBA(r, g, b, a)
+}
+
+var (
+	Black       = draw.Black
+	White       = draw.White
+	Gray        = draw.Gray
+	LightGray   = draw.LightGray
+	DarkGray    = draw.DarkGray
+	Red         = draw.Red
+	LightRed    = draw.LightRed
+	DarkRed     = draw.DarkRed
+	Green       = draw.Green
+	LightGreen  = draw.LightGreen
+	DarkGreen   = draw.DarkGreen
+	Blue        = draw.Blue
+	LightBlue   = draw.LightBlue
+	DarkBlue    = draw.DarkBlue
+	Purple      = draw.Purple
+	LightPurple = draw.LightPurple
+	DarkPurple  = draw.DarkPurple
+	Yellow      = draw.Yellow
+	LightYellow = draw.LightYellow
+	DarkYellow  = draw.DarkYellow
+	Cyan        = draw.Cyan
+	LightCyan   = draw.LightCyan
+	DarkCyan    = draw.DarkCyan
+	Brown       = draw.Brown
+	LightBrown  = draw.LightBrown
+)
+
+const (
+	LeftButton   = draw.LeftButton
+	MiddleButton = draw.MiddleButton
+	RightButton  = draw.RightButton
+)
+
+const (
+	KeyA            = draw.KeyA
+	KeyB            = draw.KeyB
+	KeyC            = draw.KeyC
+	KeyD            = draw.KeyD
+	KeyE            = draw.KeyE
+	KeyF            = draw.KeyF
+	KeyG            = draw.KeyG
+	KeyH            = draw.KeyH
+	KeyI            = draw.KeyI
+	KeyJ            = draw.KeyJ
+	KeyK            = draw.KeyK
+	KeyL            = draw.KeyL
+	KeyM            = draw.KeyM
+	KeyN            = draw.KeyN
+	KeyO            = draw.KeyO
+	KeyP            = draw.KeyP
+	KeyQ            = draw.KeyQ
+	KeyR            = draw.KeyR
+	KeyS            = draw.KeyS
+	KeyT            = draw.KeyT
+	KeyU            = draw.KeyU
+	KeyV            = draw.KeyV
+	KeyW            = draw.KeyW
+	KeyX            = draw.KeyX
+	KeyY            = draw.KeyY
+	KeyZ            = draw.KeyZ
+	Key0            = draw.Key0
+	Key1            = draw.Key1
+	Key2            = draw.Key2
+	Key3            = draw.Key3
+	Key4            = draw.Key4
+	Key5            = draw.Key5
+	Key6            = draw.Key6
+	Key7            = draw.Key7
+	Key8            = draw.Key8
+	Key9            = draw.Key9
+	KeyNum0         = draw.KeyNum0
+	KeyNum1         = draw.KeyNum1
+	KeyNum2         = draw.KeyNum2
+	KeyNum3         = draw.KeyNum3
+	KeyNum4         = draw.KeyNum4
+	KeyNum5         = draw.KeyNum5
+	KeyNum6         = draw.KeyNum6
+	KeyNum7         = draw.KeyNum7
+	KeyNum8         = draw.KeyNum8
+	KeyNum9         = draw.KeyNum9
+	KeyF1           = draw.KeyF1
+	KeyF2           = draw.KeyF2
+	KeyF3           = draw.KeyF3
+	KeyF4           = draw.KeyF4
+	KeyF5           = draw.KeyF5
+	KeyF6           = draw.KeyF6
+	KeyF7           = draw.KeyF7
+	KeyF8           = draw.KeyF8
+	KeyF9           = draw.KeyF9
+	KeyF10          = draw.KeyF10
+	KeyF11          = draw.KeyF11
+	KeyF12          = draw.KeyF12
+	KeyF13          = draw.KeyF13
+	KeyF14          = draw.KeyF14
+	KeyF15          = draw.KeyF15
+	KeyF16          = draw.KeyF16
+	KeyF17          = draw.KeyF17
+	KeyF18          = draw.KeyF18
+	KeyF19          = draw.KeyF19
+	KeyF20          = draw.KeyF20
+	KeyF21          = draw.KeyF21
+	KeyF22          = draw.KeyF22
+	KeyF23          = draw.KeyF23
+	KeyF24          = draw.KeyF24
+	KeyEnter        = draw.KeyEnter
+	KeyNumEnter     = draw.KeyNumEnter
+	KeyLeftControl  = draw.KeyLeftControl
+	KeyRightControl = draw.KeyRightControl
+	KeyLeftShift    = draw.KeyLeftShift
+	KeyRightShift   = draw.KeyRightShift
+	KeyLeftAlt      = draw.KeyLeftAlt
+	KeyRightAlt     = draw.KeyRightAlt
+	KeyLeft         = draw.KeyLeft
+	KeyRight        = draw.KeyRight
+	KeyUp           = draw.KeyUp
+	KeyDown         = draw.KeyDown
+	KeyEscape       = draw.KeyEscape
+	KeySpace        = draw.KeySpace
+	KeyBackspace    = draw.KeyBackspace
+	KeyTab          = draw.KeyTab
+	KeyHome         = draw.KeyHome
+	KeyEnd          = draw.KeyEnd
+	KeyPageDown     = draw.KeyPageDown
+	KeyPageUp       = draw.KeyPageUp
+	KeyDelete       = draw.KeyDelete
+	KeyInsert       = draw.KeyInsert
+	KeyNumAdd       = draw.KeyNumAdd
+	KeyNumSubtract  = draw.KeyNumSubtract
+	KeyNumMultiply  = draw.KeyNumMultiply
+	KeyNumDivide    = draw.KeyNumDivide
+	KeyCapslock     = draw.KeyCapslock
+	KeyPrint        = draw.KeyPrint
+	KeyPause        = draw.KeyPause
+)
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gonutz/jolina/draw"
+	"github.com/gonutz/jolina/offscreen"
+)
+
+// exportGIF implements the export-gif command. It plays a replay without a
+// window and saves the match as an animated GIF, drawn the same way as the
+// game draws it, without the menus.
+func exportGIF(args []string) error {
+	flags := flag.NewFlagSet("export-gif", flag.ExitOnError)
+	out := flags.String("out", "", "path of the GIF file, by default the replay path ending in .gif")
+	start := flags.Int("start", 0, "first frame of the replay in the GIF, there are 60 frames per second")
+	end := flags.Int("end", 0, "frame after the last one in the GIF, 0 goes to the end of the replay")
+	scale := flags.Float64("scale", 0.5, "size of the GIF relative to the field, which is 1600 pixels wide")
+	skip := flags.Int("skip", 1, "number of frames left out after each frame in the GIF, to make it smaller")
+	lang := flags.String("lang", "", "language of the texts, de or en, by default the system language is used")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: jolina export-gif [options] replay_file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	replayPath := flags.Arg(0)
+	if *out == "" {
+		*out = strings.TrimSuffix(replayPath, filepath.Ext(replayPath)) + ".gif"
+	}
+
+	if err := setLanguage(*lang); err != nil {
+		return err
+	}
+	rep, err := loadReplay(replayPath)
+	if err != nil {
+		return err
+	}
+	if *end == 0 {
+		*end = len(rep.Frames)
+	}
+	if *start < 0 || *end > len(rep.Frames) || *start >= *end {
+		return fmt.Errorf("the frames %d to %d are not in the replay, it has %d frames",
+			*start, *end, len(rep.Frames))
+	}
+	if *scale <= 0 {
+		return errors.New("the scale must be greater than 0")
+	}
+	if *skip < 0 {
+		return errors.New("the number of skipped frames cannot be negative")
+	}
+
+	m := &matchScene{match: rep.NewMatch()}
+	w := int(math.Round(float64(m.match.Width) * *scale))
+	h := int(math.Round(fieldH * *scale))
+	if w < 1 || h < 1 {
+		return errors.New("the scale is too small for a GIF")
+	}
+	window := offscreen.New(w, h)
+	anim := gif.GIF{Config: image.Config{
+		ColorModel: color.Palette(palette.Plan9),
+		Width:      w,
+		Height:     h,
+	}}
+	var last *image.Paletted
+	colorIndices := make(map[color.RGBA]uint8)
+	step := *skip + 1
+	for i := 0; i < *end; i++ {
+		m.match.Step(rep.Frames[i])
+		if i < *start || (i-*start)%step != 0 {
+			continue
+		}
+		window.Frame(func(window draw.Window) {
+			cam := newCamera(window, m.match.Width, fieldH)
+			m.draw(cam)
+			cam.drawBars()
+		})
+		frame := toPaletted(window.Image(), colorIndices)
+
+		// the delays are in 100ths of a second, they are rounded so the GIF
+		// does not drift away from the real time
+		n := (i - *start) / step
+		delay := int(math.Round(float64((n+1)*step)*100/60)) -
+			int(math.Round(float64(n*step)*100/60))
+
+		// only the part that changed is stored, the rest of the previous
+		// frame stays visible
+		if last == nil {
+			anim.Image = append(anim.Image, frame)
+			anim.Delay = append(anim.Delay, delay)
+		} else if changed := changedRect(last, frame); changed.Empty() {
+			anim.Delay[len(anim.Delay)-1] += delay
+		} else {
+			part := image.NewPaletted(changed, palette.Plan9)
+			for y := changed.Min.Y; y < changed.Max.Y; y++ {
+				copy(part.Pix[part.PixOffset(changed.Min.X, y):], frame.Pix[frame.PixOffset(changed.Min.X, y):frame.PixOffset(changed.Max.X, y)])
+			}
+			anim.Image = append(anim.Image, part)
+			anim.Delay = append(anim.Delay, delay)
+		}
+		last = frame
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	err = gif.EncodeAll(f, &anim)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		fmt.Fprintln(os.Stderr, "saved", len(anim.Image), "frames to", *out)
+	}
+	return err
+}
+
+// toPaletted converts the image to the Plan 9 palette. Looking up the closest
+// palette color is slow, so the indices are cached by color.
+func toPaletted(img *image.RGBA, indices map[color.RGBA]uint8) *image.Paletted {
+	b := img.Bounds()
+	out := image.NewPaletted(b, palette.Plan9)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			i, ok := indices[c]
+			if !ok {
+				i = uint8(out.Palette.Index(c))
+				indices[c] = i
+			}
+			out.SetColorIndex(x, y, i)
+		}
+	}
+	return out
+}
+
+// changedRect returns the smallest rectangle that contains all pixels that
+// differ in the two images of the same size.
+func changedRect(a, b *image.Paletted) image.Rectangle {
+	var r image.Rectangle
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		rowA := a.Pix[a.PixOffset(bounds.Min.X, y):a.PixOffset(bounds.Max.X, y)]
+		rowB := b.Pix[b.PixOffset(bounds.Min.X, y):b.PixOffset(bounds.Max.X, y)]
+		for x := range rowA {
+			if rowA[x] != rowB[x] {
+				r = r.Union(image.Rect(bounds.Min.X+x, y, bounds.Min.X+x+1, y+1))
+			}
+		}
+	}
+	return r
+}
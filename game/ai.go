@@ -0,0 +1,299 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// Difficulty is the strength of a computer controlled kiwi.
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+)
+
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "easy"
+	case Medium:
+		return "medium"
+	case Hard:
+		return "hard"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseDifficulty returns the Difficulty with the given name, see
+// Difficulty.String.
+func ParseDifficulty(s string) (Difficulty, error) {
+	for d := Easy; d <= Hard; d++ {
+		if d.String() == s {
+			return d, nil
+		}
+	}
+	return Easy, errors.New("unknown difficulty '" + s + "', use easy, medium or hard")
+}
+
+type aiLevel struct {
+	// reaction is the number of frames it takes for a decision to become an
+	// input
+	reaction int
+	// lookAhead is the number of frames that the ball position is predicted
+	// into the future
+	lookAhead int
+	// kickSlack is how far off the ball may be from the middle of the foot
+	// when kicking, the ball is only hit if they are less than about 50 pixels
+	// apart, larger values make the kiwi kick too early
+	kickSlack int
+	// kickChance is 1 in how many frames an aligned kiwi actually kicks
+	kickChance int
+	// charge is the percentage of Config.ChargeFrames that the kiwi charges
+	// its kicks for, unless a harder kick scores
+	charge int
+	// anticipate makes the kiwi step back when the opponent is about to kick
+	// the ball towards its goal
+	anticipate bool
+}
+
+// aiFlatKickDistance and aiLobDistance are how far in front of the foot the
+// AI wants the ball to be when it kicks flat or lobs the ball over the
+// opponent, see AI.decide.
+const (
+	aiFlatKickDistance = 46
+	aiLobDistance      = 30
+)
+
+// aiChargeDistance is how close the AI has to be to where it wants to kick the
+// ball to start charging the kick.
+const aiChargeDistance = 250
+
+// aiShotFrames is how many frames the AI follows a kick to see if it scores.
+const aiShotFrames = 3 * 60
+
+var aiLevels = [...]aiLevel{
+	Easy:   {reaction: 20, lookAhead: 0, kickSlack: 70, kickChance: 4, charge: 30},
+	Medium: {reaction: 10, lookAhead: 5, kickSlack: 45, kickChance: 2, charge: 60},
+	Hard:   {reaction: 3, lookAhead: 12, kickSlack: 20, kickChance: 1, charge: 100, anticipate: true},
+}
+
+// AI controls one of the kiwis. Call Input once per frame, before Match.Step,
+// to get the kiwi's controls. If its teammate is closer to the ball, the kiwi
+// stays back to defend.
+type AI struct {
+	player  int
+	level   aiLevel
+	rng     *rand.Rand
+	pending []PlayerInput
+	// last is the input that was returned last
+	last PlayerInput
+	// held is the number of decisions that the kick button has been held for,
+	// rest is the number of decisions to wait after releasing it before it is
+	// held again, while the kiwi cannot kick
+	held, rest int
+}
+
+// NewAI creates a computer player for the kiwi with the given player number.
+// Its random decisions are made from the given seed.
+func NewAI(player int, d Difficulty, seed int64) *AI {
+	return &AI{
+		player: player,
+		level:  aiLevels[d],
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Player returns the player number of the kiwi controlled by the AI.
+func (a *AI) Player() int {
+	return a.player
+}
+
+// Input decides what the kiwi does in this frame. The decision is based on
+// the match state some frames ago, depending on the reaction time.
+func (a *AI) Input(m *Match) PlayerInput {
+	a.pending = append(a.pending, a.decide(m))
+	if len(a.pending) <= a.level.reaction {
+		return PlayerInput{}
+	}
+	in := a.pending[0]
+	a.pending = a.pending[1:]
+	if m.Over() {
+		// do not restart a finished match, leave that to the humans
+		in.Shoot = false
+	}
+	a.last = in
+	return in
+}
+
+func (a *AI) decide(m *Match) PlayerInput {
+	team := Team(a.player)
+	me := &m.Players[a.player]
+	shootX, otherShootX := LeftKiwiShootX, RightKiwiShootX
+	// forward is the direction that the kiwi kicks in
+	forward := 1
+	if m.End(team) == RightSide {
+		shootX, otherShootX = otherShootX, shootX
+		forward = -1
+	}
+
+	ball := a.predictBall(m) + (BallHitBoxX[0]+BallHitBoxX[1])/2
+	// the opponent is the other team's kiwi closest to the ball, the kiwi
+	// defends if a teammate is closer to the ball than itself
+	var other *Player
+	otherFoot := 0
+	defend := false
+	for i := range m.Players {
+		p := &m.Players[i]
+		if Team(i) == team {
+			if i != a.player {
+				mate := abs(p.X + (shootX[0]+shootX[1])/2 - ball)
+				mine := abs(me.X + (shootX[0]+shootX[1])/2 - ball)
+				defend = defend || mate < mine || mate == mine && i < a.player
+			}
+			continue
+		}
+		foot := p.X + (otherShootX[0]+otherShootX[1])/2
+		if other == nil || abs(foot-ball) < abs(otherFoot-ball) {
+			other, otherFoot = p, foot
+		}
+	}
+	speed := m.Config.KiwiSpeed
+	foot := me.X + (shootX[0]+shootX[1])/2
+	charging := m.Config.ChargeFrames > 0
+	// the kiwi knows where it is going to walk in its pending inputs, without
+	// this it would always overshoot its target, it cannot walk while kicking
+	cooldown := me.ShootCooldown
+	held := a.last.Shoot
+	for _, in := range a.pending {
+		if cooldown > 0 {
+			cooldown--
+		}
+		kicks := in.Shoot && !held
+		if charging {
+			kicks = held && !in.Shoot
+		}
+		held = in.Shoot
+		if kicks && cooldown == 0 {
+			cooldown = m.Config.ShootCooldown
+		}
+		if cooldown != 0 {
+			continue
+		}
+		if in.Left {
+			foot -= speed
+		}
+		if in.Right {
+			foot += speed
+		}
+	}
+
+	// the foot should be where the ball is when kicking, but with collisions
+	// the ball cannot get past the legs and walking right up to it would only
+	// push it away, so the kiwi stops with the ball in front of its foot. The
+	// closer the ball is to the foot, the higher it flies, which is needed to
+	// get it past the opponent's legs.
+	kickAt := ball
+	if m.Config.Collisions {
+		kickAt -= forward * aiFlatKickDistance
+		if (otherFoot-ball)*forward > 0 {
+			kickAt = ball - forward*aiLobDistance
+		}
+	}
+	target := kickAt
+	if defend {
+		// stay between the ball and our own goal
+		target -= forward * KiwiW
+	}
+	otherCanKick := other.ShootCooldown == 0
+	if charging {
+		otherCanKick = other.Charge > 0
+	}
+	if a.level.anticipate && otherCanKick && abs(otherFoot-ball) < 100 {
+		// the opponent is about to kick, wait for the ball a bit closer to
+		// our own goal
+		target -= forward * KiwiW / 2
+	}
+
+	var in PlayerInput
+	if d := target - foot; d < -speed/2 {
+		in.Left = true
+	} else if d > speed/2 {
+		in.Right = true
+	}
+	aligned := abs(kickAt-foot) < a.level.kickSlack && m.BallY <= KickHeight &&
+		a.rng.Intn(a.level.kickChance) == 0
+	if !charging {
+		// a kick needs a new button press
+		in.Shoot = aligned && a.held == 0
+		a.held = 0
+		if in.Shoot {
+			a.held = 1
+		}
+		return in
+	}
+	// charge the kick while getting close to the ball and release it when the
+	// ball is in the right place
+	if a.rest > 0 {
+		a.rest--
+	} else if a.held > 0 {
+		// the kiwi kicks as soon as the kick scores, it only charges longer
+		// than its level allows if a harder kick scores
+		release := false
+		if aligned {
+			x := foot - (shootX[0]+shootX[1])/2
+			charged := a.held >= m.Config.ChargeFrames*a.level.charge/100
+			release = a.scores(m, x, a.held) ||
+				charged && !a.scoresHarder(m, x, a.held)
+		}
+		if release {
+			a.held = 0
+			a.rest = m.Config.ShootCooldown
+		} else {
+			in.Shoot = true
+			a.held++
+		}
+	} else if abs(kickAt-foot) < aiChargeDistance {
+		in.Shoot = true
+		a.held = 1
+	}
+	return in
+}
+
+// scores reports whether the kiwi scores a goal for its team if it stands at x
+// and kicks with the given charge, while the other kiwis stand still.
+func (a *AI) scores(m *Match, x, charge int) bool {
+	sim := m.Clone()
+	me := &sim.Players[a.player]
+	me.X, me.Charge, me.ShootCooldown = x, charge, 0
+	for i := 0; i < aiShotFrames; i++ {
+		for _, e := range sim.Step(Inputs{}) {
+			if e.Type == Goal {
+				return e.Side == Team(a.player)
+			}
+		}
+	}
+	return false
+}
+
+// scoresHarder reports whether a kick with more than the given charge scores,
+// see AI.scores.
+func (a *AI) scoresHarder(m *Match, x, charge int) bool {
+	for c := charge + 1; c <= m.Config.ChargeFrames; c++ {
+		if a.scores(m, x, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// predictBall returns where the ball will be after the AI's look ahead time.
+func (a *AI) predictBall(m *Match) int {
+	x, y, vx, vy := m.BallX, m.BallY, m.BallVx, m.BallVy
+	for i := 0; i < a.level.lookAhead; i++ {
+		moveBall(&m.Config, &x, &y, &vx, &vy)
+	}
+	return x
+}
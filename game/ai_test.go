@@ -0,0 +1,31 @@
+package game
+
+import "testing"
+
+func TestAIScoresAgainstIdleKiwi(t *testing.T) {
+	tests := []struct {
+		name       string
+		difficulty Difficulty
+		player     int
+	}{
+		{"medium from the left", Medium, 0},
+		{"hard from the left", Hard, 0},
+		{"hard from the right", Hard, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch(1600, 1, DefaultConfig())
+			ai := NewAI(tt.player, tt.difficulty, 1)
+			for i := 0; i < 60*60; i++ {
+				var in Inputs
+				in.Players[tt.player] = ai.Input(m)
+				for _, e := range m.Step(in) {
+					if e.Type == Goal && e.Side == Team(tt.player) {
+						return
+					}
+				}
+			}
+			t.Errorf("the AI did not score in a minute, the score is %v", m.Score)
+		})
+	}
+}
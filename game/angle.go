@@ -0,0 +1,24 @@
+package game
+
+import "math"
+
+// sines are the sines of the angles from 0 to 90 degrees, times 1000. The
+// match only uses integers so it plays out the same on every computer, even
+// if their floating point math differs in the last digits.
+var sines = func() [91]int {
+	var s [91]int
+	for deg := range s {
+		s[deg] = int(math.Round(1000 * math.Sin(float64(deg)*math.Pi/180)))
+	}
+	return s
+}()
+
+// sinDeg returns 1000 times the sine of an angle between 0 and 90 degrees.
+func sinDeg(deg int) int {
+	return sines[deg]
+}
+
+// cosDeg returns 1000 times the cosine of an angle between 0 and 90 degrees.
+func cosDeg(deg int) int {
+	return sines[90-deg]
+}
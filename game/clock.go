@@ -0,0 +1,75 @@
+package game
+
+// HalftimeFrames is the pause after the end of a half before the game goes
+// on.
+const HalftimeFrames = 120
+
+// Timed reports whether the match is played in two halves of
+// Config.HalfFrames instead of up to Config.WinScore goals.
+func (m *Match) Timed() bool {
+	return m.Config.HalfFrames > 0 && !m.Config.Practice
+}
+
+// End returns the end of the field where the goal of the given team is. The
+// teams change ends for the second half, the blue kiwis then play from the
+// right like the white kiwis did before. Since the teams only swap, End also
+// returns the team that plays from a given end.
+func (m *Match) End(team Side) Side {
+	if m.Half == 2 {
+		if team == LeftSide {
+			return RightSide
+		}
+		return LeftSide
+	}
+	return team
+}
+
+// kickOffX is where the kiwi with the given player number starts after a
+// pause. The first kiwi of a team stands in front of its goal, the second one
+// a quarter of the field further.
+func (m *Match) kickOffX(player int) int {
+	x := 0
+	if player >= 2 {
+		x = m.Width/4 - KiwiW/2
+	}
+	if m.End(Team(player)) == LeftSide {
+		return x
+	}
+	return m.Width - KiwiW - x
+}
+
+// tickClock counts down the time of the current half, it is called for every
+// frame that the ball is in play. When the half is over, the game pauses.
+func (m *Match) tickClock(events []Event) []Event {
+	if !m.Timed() || m.Overtime || m.scoringTimer > 0 {
+		return events
+	}
+	m.Clock--
+	if m.Clock <= 0 {
+		m.Clock = 0
+		m.scoringTimer = HalftimeFrames
+		events = append(events, Event{Type: TimeUp})
+	}
+	return events
+}
+
+// resume is called at the end of a pause in a timed match. After the first
+// half, the teams change ends. After the second half, the team with more goals
+// wins or, if it is a tie, the overtime starts and the next goal wins.
+func (m *Match) resume() {
+	if m.Overtime {
+		// the golden goal was scored
+		m.LeftWon = m.Score[LeftSide] > m.Score[RightSide]
+		m.RightWon = m.Score[RightSide] > m.Score[LeftSide]
+	} else if m.Clock == 0 {
+		if m.Half == 1 {
+			m.Half = 2
+			m.Clock = m.Config.HalfFrames
+		} else if m.Score[LeftSide] == m.Score[RightSide] {
+			m.Overtime = true
+		} else {
+			m.LeftWon = m.Score[LeftSide] > m.Score[RightSide]
+			m.RightWon = m.Score[RightSide] > m.Score[LeftSide]
+		}
+	}
+}
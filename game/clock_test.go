@@ -0,0 +1,131 @@
+package game
+
+import "testing"
+
+const testHalfFrames = 100
+
+func newTimedMatch() *Match {
+	c := DefaultConfig()
+	c.HalfFrames = testHalfFrames
+	return NewMatch(1600, 1, c)
+}
+
+// step advances the match by n frames without any inputs and returns all
+// events that happened.
+func step(m *Match, n int) []Event {
+	var events []Event
+	for i := 0; i < n; i++ {
+		events = append(events, m.Step(Inputs{})...)
+	}
+	return events
+}
+
+func countEvents(events []Event, typ EventType) int {
+	n := 0
+	for _, e := range events {
+		if e.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHalfEndsAndTeamsChangeEnds(t *testing.T) {
+	m := newTimedMatch()
+	if events := step(m, testHalfFrames-1); countEvents(events, TimeUp) != 0 {
+		t.Fatal("the time was up before the end of the half")
+	}
+	if m.Clock != 1 || !m.InPlay() {
+		t.Fatalf("the clock is at %d before the last frame of the half", m.Clock)
+	}
+	if events := step(m, 1); countEvents(events, TimeUp) != 1 {
+		t.Fatalf("the time was not up after the half, the events are %v", events)
+	}
+	if m.InPlay() || m.Half != 1 {
+		t.Fatal("the ball is still in play after the time was up")
+	}
+
+	step(m, HalftimeFrames)
+	if m.Half != 2 || m.Clock != testHalfFrames || !m.InPlay() {
+		t.Fatalf("the second half did not start, half %d with %d frames left",
+			m.Half, m.Clock)
+	}
+	if m.End(LeftSide) != RightSide || m.End(RightSide) != LeftSide {
+		t.Error("the teams did not change ends")
+	}
+	for i, p := range m.Players {
+		want := 1600 - KiwiW
+		if Team(i) == RightSide {
+			want = 0
+		}
+		if p.X != want {
+			t.Errorf("kiwi %d kicks off at %d but want %d", i, p.X, want)
+		}
+	}
+	if m.BallX != (1600-BallW)/2 || m.BallY != 0 {
+		t.Errorf("the ball kicks off at %d,%d", m.BallX, m.BallY)
+	}
+}
+
+func TestTimedMatchEnd(t *testing.T) {
+	tests := []struct {
+		name     string
+		score    [2]int
+		overtime bool
+		leftWon  bool
+		rightWon bool
+	}{
+		{"tie without goals", [2]int{0, 0}, true, false, false},
+		{"tie", [2]int{2, 2}, true, false, false},
+		{"blue wins", [2]int{3, 1}, false, true, false},
+		{"white wins", [2]int{0, 1}, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTimedMatch()
+			step(m, testHalfFrames+HalftimeFrames)
+			m.Score = tt.score
+			step(m, testHalfFrames+HalftimeFrames)
+			if m.Overtime != tt.overtime || m.LeftWon != tt.leftWon || m.RightWon != tt.rightWon {
+				t.Fatalf("overtime, blue won and white won are %v, %v, %v but want %v, %v, %v",
+					m.Overtime, m.LeftWon, m.RightWon, tt.overtime, tt.leftWon, tt.rightWon)
+			}
+			if m.Over() {
+				events := step(m, m.Config.WinSoundCooldown)
+				if countEvents(events, Win) != 1 {
+					t.Errorf("want one Win event but got %v", events)
+				}
+			}
+		})
+	}
+}
+
+func TestGoldenGoalWinsOvertime(t *testing.T) {
+	m := newTimedMatch()
+	step(m, 2*(testHalfFrames+HalftimeFrames))
+	if !m.Overtime || !m.InPlay() {
+		t.Fatal("the overtime did not start after a tie")
+	}
+	clock := m.Clock
+	step(m, 3*testHalfFrames)
+	if m.Clock != clock || !m.InPlay() {
+		t.Fatal("the clock runs in the overtime")
+	}
+
+	// in the second half, the white team plays from the left end and scores
+	// in the goal at the right end
+	m.BallX = 1600 - m.Config.GoalWidth
+	m.BallVx = 10
+	events := step(m, 10)
+	if countEvents(events, Goal) != 1 || m.Score != [2]int{0, 1} {
+		t.Fatalf("the golden goal was not scored, the score is %v", m.Score)
+	}
+	if m.Over() {
+		t.Fatal("the match is over before the pause after the goal")
+	}
+	step(m, ScoringFrames)
+	if !m.RightWon || m.LeftWon {
+		t.Fatalf("blue won is %v, white won is %v after the golden goal",
+			m.LeftWon, m.RightWon)
+	}
+}
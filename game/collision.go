@@ -0,0 +1,80 @@
+package game
+
+var (
+	// LeftKiwiBodyX and RightKiwiBodyX are the bodies of the kiwis, the kiwis
+	// cannot walk through each other's bodies. They are narrower than the
+	// drawn bellies so that a ball between two kiwis that stand belly to belly
+	// is always in reach of one of them.
+	LeftKiwiBodyX  = [2]int{15, 160}
+	RightKiwiBodyX = [2]int{140, 328}
+	// LeftKiwiLegsX and RightKiwiLegsX are the parts of the kiwis that the
+	// ball bumps into, up to a height of LegsHeight. The legs are narrower
+	// than the drawn legs, the ball has to get close to the foot to be kicked.
+	LeftKiwiLegsX  = [2]int{95, 120}
+	RightKiwiLegsX = [2]int{183, 208}
+)
+
+// LegsHeight is how high the legs reach, the ball flies over them above that.
+const LegsHeight = 95
+
+// Legs returns the part of the kiwi with the given player number that the
+// ball bounces off.
+func (m *Match) Legs(player int) Rect {
+	p := &m.Players[player]
+	if m.End(Team(player)) == LeftSide {
+		return Rect{X: p.X + LeftKiwiLegsX[0], W: LeftKiwiLegsX[1] - LeftKiwiLegsX[0], H: LegsHeight}
+	}
+	return Rect{X: p.X + RightKiwiLegsX[0], W: RightKiwiLegsX[1] - RightKiwiLegsX[0], H: LegsHeight}
+}
+
+// bodyX returns the body of a kiwi playing from the given end.
+func bodyX(end Side) [2]int {
+	if end == LeftSide {
+		return LeftKiwiBodyX
+	}
+	return RightKiwiBodyX
+}
+
+// collideKiwis keeps two kiwis, playing from the ends aEnd and bEnd, from
+// walking through each other after they moved by aVx and bVx in this frame. A
+// kiwi walking into the other one pushes it along at half its speed, kiwis
+// that push against each other both stand still. The kiwis stay within their
+// limits of movement.
+func collideKiwis(a, b *Player, aEnd, bEnd Side, aVx, bVx int, aLimits, bLimits [2]int) {
+	aBody, bBody := bodyX(aEnd), bodyX(bEnd)
+	if a.X+(aBody[0]+aBody[1])/2 > b.X+(bBody[0]+bBody[1])/2 {
+		// make a the one on the left
+		a, b = b, a
+		aBody, bBody = bBody, aBody
+		aVx, bVx = bVx, aVx
+		aLimits, bLimits = bLimits, aLimits
+	}
+	left, right := a, b
+	leftVx, rightVx := aVx, bVx
+	leftLimits, rightLimits := aLimits, bLimits
+	overlap := left.X + aBody[1] - (right.X + bBody[0])
+	if overlap <= 0 {
+		return
+	}
+	leftPushes, rightPushes := leftVx > 0, rightVx < 0
+	switch {
+	case leftPushes && !rightPushes:
+		right.X += overlap / 2
+		left.X -= overlap - overlap/2
+	case rightPushes && !leftPushes:
+		left.X -= overlap / 2
+		right.X += overlap - overlap/2
+	default:
+		left.X -= overlap / 2
+		right.X += overlap - overlap/2
+	}
+	// a kiwi that is pushed against its limit does not give way anymore
+	if right.X > rightLimits[1] {
+		left.X -= right.X - rightLimits[1]
+		right.X = rightLimits[1]
+	}
+	if left.X < leftLimits[0] {
+		right.X += leftLimits[0] - left.X
+		left.X = leftLimits[0]
+	}
+}
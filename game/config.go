@@ -0,0 +1,146 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Config contains the values that tune the rules of a Match. Times are given
+// in frames, speeds in pixels per frame.
+type Config struct {
+	// Players is the number of kiwis in the match, 2 for one against one or
+	// MaxPlayers for two against two.
+	Players   int `json:"players"`
+	KiwiSpeed int `json:"kiwiSpeed"`
+	// ShootFrames is the number of frames that a kick is shown.
+	ShootFrames int `json:"shootFrames"`
+	// ShootCooldown is the number of frames after a kick before the kiwi can
+	// kick or walk again.
+	ShootCooldown int `json:"shootCooldown"`
+	// a kick gives the ball a speed between MinBallShootSpeed and
+	// MaxBallShootSpeed, depending on how long it was charged
+	MinBallShootSpeed int `json:"minBallShootSpeed"`
+	MaxBallShootSpeed int `json:"maxBallShootSpeed"`
+	// ChargeFrames is how long the kick button has to be held down for the
+	// fastest kick, the kick happens when it is released. If ChargeFrames is
+	// 0, the kick happens when the button is pressed and the ball gets a
+	// random speed between MinBallShootSpeed (inclusive) and
+	// MaxBallShootSpeed (exclusive, unless it is the same).
+	ChargeFrames int `json:"chargeFrames"`
+	// KickVariation is how much the speed of a charged kick may randomly
+	// differ from the charged speed, up or down.
+	KickVariation int `json:"kickVariation"`
+	// BallFriction is subtracted from the ball's speed every frame that it
+	// rolls on the ground.
+	BallFriction int `json:"ballFriction"`
+	// Gravity is subtracted from the ball's vertical speed every frame that it
+	// is in the air.
+	Gravity int `json:"gravity"`
+	// BounceRestitution is the percentage of its vertical speed that the ball
+	// keeps when it bounces off the ground.
+	BounceRestitution int `json:"bounceRestitution"`
+	// MaxKickAngle is the steepest angle in degrees that the ball can be
+	// kicked at, see Match.Step. 0 means the ball is always kicked flat along
+	// the ground.
+	MaxKickAngle int `json:"maxKickAngle"`
+	// GoalWidth is how far the goals reach into the field, GoalHeight is the
+	// height of the crossbar. If GoalWidth is 0 there are no goal frames and
+	// the ball only has to leave the field to score.
+	GoalWidth  int `json:"goalWidth"`
+	GoalHeight int `json:"goalHeight"`
+	// KeeperZone is the width of the zone in front of each goal that only the
+	// kiwi defending the goal may step into.
+	KeeperZone int `json:"keeperZone"`
+	// Collisions makes the kiwis bump into each other and into the ball.
+	Collisions bool `json:"collisions"`
+	// HalfFrames is the length of a half in a timed match. If it is 0, the
+	// match is not timed and the first team with WinScore goals wins.
+	HalfFrames int `json:"halfFrames"`
+	// WinScore is the number of goals needed to win.
+	WinScore int `json:"winScore"`
+	// WinSoundCooldown is the time between the winning goal and the Win event.
+	WinSoundCooldown int `json:"winSoundCooldown"`
+	// Practice leaves the white team out of the match so the blue kiwis can
+	// practice alone. A practice match never ends.
+	Practice bool `json:"practice,omitempty"`
+}
+
+// DefaultConfig returns the settings that a new game is played with.
+func DefaultConfig() Config {
+	return Config{
+		Players:           2,
+		KiwiSpeed:         15,
+		ShootFrames:       6,
+		ShootCooldown:     6 + 8,
+		MinBallShootSpeed: 30,
+		MaxBallShootSpeed: 50,
+		ChargeFrames:      40,
+		KickVariation:     0,
+		BallFriction:      3,
+		Gravity:           2,
+		BounceRestitution: 60,
+		MaxKickAngle:      60,
+		GoalWidth:         120,
+		GoalHeight:        180,
+		KeeperZone:        150,
+		Collisions:        true,
+		HalfFrames:        0,
+		WinScore:          10,
+		WinSoundCooldown:  40,
+	}
+}
+
+// Validate returns an error describing the first value that is out of range.
+func (c *Config) Validate() error {
+	type limit struct {
+		name     string
+		value    int
+		min, max int
+	}
+	for _, l := range []limit{
+		{"kiwiSpeed", c.KiwiSpeed, 1, 100},
+		{"shootFrames", c.ShootFrames, 1, 120},
+		{"shootCooldown", c.ShootCooldown, c.ShootFrames, 240},
+		{"minBallShootSpeed", c.MinBallShootSpeed, 0, 500},
+		{"maxBallShootSpeed", c.MaxBallShootSpeed, c.MinBallShootSpeed, 500},
+		{"chargeFrames", c.ChargeFrames, 0, 600},
+		{"kickVariation", c.KickVariation, 0, c.MinBallShootSpeed},
+		{"ballFriction", c.BallFriction, 0, 100},
+		{"gravity", c.Gravity, 1, 100},
+		// a ball that keeps all of its speed would bounce forever
+		{"bounceRestitution", c.BounceRestitution, 0, 95},
+		{"maxKickAngle", c.MaxKickAngle, 0, 80},
+		{"goalWidth", c.GoalWidth, 0, 500},
+		{"goalHeight", c.GoalHeight, BallH, 500},
+		{"keeperZone", c.KeeperZone, 0, 500},
+		{"halfFrames", c.HalfFrames, 0, 60 * 60 * 60},
+		{"winScore", c.WinScore, 1, 1000},
+		// the Win event happens when the cooldown counts down to 1
+		{"winSoundCooldown", c.WinSoundCooldown, 2, 600},
+	} {
+		if l.value < l.min || l.value > l.max {
+			return fmt.Errorf("%s is %d but must be between %d and %d", l.name, l.value, l.min, l.max)
+		}
+	}
+	if c.Players != 2 && c.Players != MaxPlayers {
+		return fmt.Errorf("players is %d but must be 2 or %d", c.Players, MaxPlayers)
+	}
+	// the whole ball has to fit into the goal
+	if ballW := BallHitBoxX[1] - BallHitBoxX[0]; c.GoalWidth != 0 && c.GoalWidth <= ballW {
+		return fmt.Errorf("goalWidth is %d but must be 0 or more than %d", c.GoalWidth, ballW)
+	}
+	return nil
+}
+
+// LoadConfig reads a JSON config. Values that are not in the JSON keep their
+// default values. The config is validated.
+func LoadConfig(r io.Reader) (Config, error) {
+	c := DefaultConfig()
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
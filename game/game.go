@@ -0,0 +1,607 @@
+// Package game contains the rules of the kiwi soccer game. A Match is advanced
+// one frame at a time by calling Step. It does not draw anything or play any
+// sounds, instead Step reports what happened in the frame as Events, so the
+// rules can run without a window.
+package game
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	KiwiW, KiwiH = 343, 300
+	BallW, BallH = 60, 60
+	// ScoringFrames is the pause after a goal before the ball is put back in
+	// the middle.
+	ScoringFrames = 60
+	// winRestartDelay is the number of frames after the Win event before the
+	// match can be restarted.
+	winRestartDelay = 90
+	// KickHeight is the highest that the ball can be above the ground to be
+	// kicked, kiwis cannot reach balls that fly over them.
+	KickHeight = 50
+)
+
+var (
+	LeftKiwiShootX  = [2]int{86, 143}
+	RightKiwiShootX = [2]int{160, 218}
+	BallHitBoxX     = [2]int{7, 52}
+)
+
+// Side is a team, the blue kiwis start at the left end of the field and the
+// white kiwis at the right end.
+type Side int
+
+const (
+	LeftSide Side = iota
+	RightSide
+)
+
+// MaxPlayers is the most kiwis that can play in a match, two teams of two.
+const MaxPlayers = 4
+
+// Team returns the team of the kiwi with the given player number. The numbers
+// take turns between the teams, 0 and 2 are blue, 1 and 3 are white.
+func Team(player int) Side {
+	return Side(player % 2)
+}
+
+// EventType tells what happened in an Event.
+type EventType int
+
+const (
+	// Kick means a kiwi kicked, it might miss the ball.
+	Kick EventType = iota
+	// BallHit means a kiwi's kick hit the ball.
+	BallHit
+	// Goal means a team scored a goal.
+	Goal
+	// Win means a team won the match, this is reported with a short delay
+	// after the winning goal.
+	Win
+	// Restart means the match was started over.
+	Restart
+	// TimeUp means a half of a timed match is over, see Match.Timed.
+	TimeUp
+)
+
+// Event is something that happened during a call to Match.Step. Side is the
+// team of the kiwi that kicked or hit the ball or the team that scored or
+// won. It is not used for Restart and TimeUp. For Kick, BallHit and Goal, X is
+// where on the field it happened and Speed is the speed of the ball in pixels
+// per frame after it was kicked or when it went into the goal. A Kick that
+// misses the ball has a Speed of 0.
+type Event struct {
+	Type  EventType
+	Side  Side
+	X     int
+	Speed int
+}
+
+// PlayerInput is the state of one kiwi's controls in a frame. Shoot, Left and
+// Right are true while the kick button or the direction is held down.
+type PlayerInput struct {
+	Shoot, Left, Right bool
+}
+
+// Inputs are all controls for a frame, Players are the controls of the kiwis
+// by their player numbers. Restart starts the match over. A running match
+// restarts right away, a finished match once the restart delay has passed,
+// just like when any kiwi kicks.
+type Inputs struct {
+	Players [MaxPlayers]PlayerInput
+	Restart bool
+}
+
+// Player is one kiwi. ShootFrames counts down while the kick is shown,
+// ShootCooldown counts down until the kiwi can kick or walk again. Charge
+// counts the frames that the kick button is held, up to Config.ChargeFrames.
+type Player struct {
+	X             int
+	ShootFrames   int
+	ShootCooldown int
+	Charge        int
+}
+
+// Match is the state of a game between two teams of kiwis on a field of the
+// given Width in pixels. Players are the kiwis by their player numbers, see
+// Team. Score has the goals of both teams by Side, the teams keep their Side
+// even after they changed ends, see End. BallY is the height of the ball
+// above the ground, BallVy is positive while the ball flies up. In a timed
+// match, Half is 1 or 2 and Clock is the number of frames left in it, in the
+// Overtime the clock stops and the next goal wins.
+type Match struct {
+	Config       Config
+	Width        int
+	Players      []Player
+	Score        [2]int
+	BallX        int
+	BallY        int
+	BallVx       int
+	BallVy       int
+	BallRotation int
+	LeftWon      bool
+	RightWon     bool
+	Half         int
+	Clock        int
+	Overtime     bool
+
+	seed                int64
+	src                 *source
+	rng                 *rand.Rand
+	scoringTimer        int
+	winSoundTimer       int
+	winShowRestartTimer int
+	// lastInputs are the inputs of the previous frame, to know when a button
+	// was pressed
+	lastInputs Inputs
+}
+
+// NewMatch creates a match with Config.Players kiwis at their ends of a field
+// of the given width and the ball in the middle. All randomness in the match
+// comes from a generator with the given seed, two matches with the same width,
+// seed and config that get the same Inputs will play out exactly the same.
+// The config must be valid, see Config.Validate.
+func NewMatch(width int, seed int64, config Config) *Match {
+	m := &Match{
+		Config:  config,
+		Width:   width,
+		Players: make([]Player, config.Players),
+		seed:    seed,
+		src:     &source{state: uint64(seed)},
+	}
+	m.rng = rand.New(m.src)
+	m.BallX = (width - BallW) / 2
+	m.Half = 1
+	m.Clock = config.HalfFrames
+	for i := range m.Players {
+		m.Players[i].X = m.kickOffX(i)
+	}
+	return m
+}
+
+// Clone returns an independent copy of the match, including the state of its
+// random number generator. Stepping the copy does not change the original.
+func (m *Match) Clone() *Match {
+	c := *m
+	c.Players = append([]Player(nil), m.Players...)
+	src := *m.src
+	c.src = &src
+	c.rng = rand.New(c.src)
+	return &c
+}
+
+// Seed returns the seed that the match was created with.
+func (m *Match) Seed() int64 {
+	return m.seed
+}
+
+// Over reports whether one of the teams has won.
+func (m *Match) Over() bool {
+	return m.LeftWon || m.RightWon
+}
+
+// InPlay reports whether the ball is in play. It is not during the pause after
+// a goal or at halftime and when the match is over.
+func (m *Match) InPlay() bool {
+	return m.scoringTimer == 0 && !m.Over()
+}
+
+// CanRestart reports whether the match is over and the restart delay has
+// passed, so kicking or Inputs.Restart start a new match.
+func (m *Match) CanRestart() bool {
+	return m.Over() && m.winShowRestartTimer == 0
+}
+
+// OnField reports whether the kiwi with the given player number plays, in
+// practice the white team is not on the field.
+func (m *Match) OnField(player int) bool {
+	return !m.Config.Practice || Team(player) == LeftSide
+}
+
+// Restart resets the scores, players and the clock. The ball stays where it
+// is, after the winning goal it was already put back in the middle.
+func (m *Match) Restart() {
+	m.Half = 1
+	m.Clock = m.Config.HalfFrames
+	m.Overtime = false
+	for i := range m.Players {
+		m.Players[i] = Player{X: m.kickOffX(i)}
+	}
+	m.Score = [2]int{}
+	m.scoringTimer = 0
+	m.LeftWon, m.RightWon = false, false
+	m.winSoundTimer = 0
+	m.winShowRestartTimer = 0
+	m.BallRotation = 0
+}
+
+// Step advances the match by one frame.
+func (m *Match) Step(in Inputs) []Event {
+	if in.Restart && !m.Over() {
+		// a running match can be started over at any time, e.g. from a pause
+		// menu, a finished match only after the restart delay
+		m.Restart()
+		m.centerBall()
+		m.lastInputs = in
+		return []Event{{Type: Restart}}
+	}
+
+	var events []Event
+
+	if m.scoringTimer > 0 {
+		m.scoringTimer--
+		if m.scoringTimer == 0 {
+			if m.Timed() {
+				m.resume()
+			}
+			m.winSoundTimer = 0
+			for i := range m.Players {
+				p := &m.Players[i]
+				p.ShootFrames = 0
+				p.ShootCooldown = 0
+				p.Charge = 0
+				p.X = m.kickOffX(i)
+			}
+			m.centerBall()
+			if !m.Config.Practice && !m.Timed() {
+				if m.Score[LeftSide] >= m.Config.WinScore {
+					m.LeftWon = true
+				}
+				if m.Score[RightSide] >= m.Config.WinScore {
+					m.RightWon = true
+				}
+			}
+			if m.Over() {
+				m.winSoundTimer = m.Config.WinSoundCooldown
+				m.winShowRestartTimer = m.Config.WinSoundCooldown + winRestartDelay
+			}
+		}
+	} else if m.Over() {
+		// if the restart instruction is showing and any player presses the
+		// kick button restart the game
+		restart := in.Restart
+		for i, p := range in.Players {
+			restart = restart || p.Shoot && !m.lastInputs.Players[i].Shoot
+		}
+		if m.winShowRestartTimer == 0 && restart {
+			m.Restart()
+			events = append(events, Event{Type: Restart})
+		}
+	} else {
+		events = m.play(in, events)
+	}
+
+	if m.Over() {
+		m.winSoundTimer--
+		if m.winSoundTimer < 0 {
+			m.winSoundTimer = 0
+		}
+		if m.winSoundTimer == 1 {
+			if m.LeftWon {
+				events = append(events, Event{Type: Win, Side: LeftSide})
+			}
+			if m.RightWon {
+				events = append(events, Event{Type: Win, Side: RightSide})
+			}
+		}
+		m.winShowRestartTimer--
+		if m.winShowRestartTimer < 0 {
+			m.winShowRestartTimer = 0
+		}
+	}
+
+	m.lastInputs = in
+	return events
+}
+
+// ballCenter is the x position of the middle of the ball.
+func (m *Match) ballCenter() int {
+	return m.BallX + (BallHitBoxX[0]+BallHitBoxX[1])/2
+}
+
+// ballSpeed is the length of the ball's velocity, rounded down.
+func (m *Match) ballSpeed() int {
+	return int(math.Sqrt(float64(m.BallVx*m.BallVx + m.BallVy*m.BallVy)))
+}
+
+// centerBall puts the ball back on the ground in the middle of the field.
+func (m *Match) centerBall() {
+	m.BallX = (m.Width - BallW) / 2
+	m.BallY = 0
+	m.BallVx = 0
+	m.BallVy = 0
+	m.BallRotation = 0
+}
+
+func (m *Match) play(in Inputs, events []Event) []Event {
+	c := &m.Config
+	last := m.lastInputs
+
+	// shoot
+	var startX [MaxPlayers]int
+	for i := range m.Players {
+		m.Players[i].cool()
+		startX[i] = m.Players[i].X
+	}
+	ballLeft := m.BallX + BallHitBoxX[0]
+	ballRight := m.BallX + BallHitBoxX[1]
+	hits := func(p *Player, shootX [2]int) bool {
+		shootLeft := p.X + shootX[0]
+		shootRight := p.X + shootX[1]
+		d := abs((ballLeft+ballRight)/2 - (shootLeft+shootRight)/2)
+		return d < (ballRight-ballLeft)/2+(shootRight-shootLeft)/2 &&
+			m.BallY <= KickHeight
+	}
+	shoot := func(p *Player, in, last PlayerInput, team Side) {
+		if p.ShootCooldown != 0 {
+			return
+		}
+		charge := 0
+		if c.ChargeFrames == 0 {
+			// kick right away
+			if !in.Shoot || last.Shoot {
+				return
+			}
+		} else if in.Shoot {
+			// the kick button is held, charge the kick
+			if p.Charge < c.ChargeFrames {
+				p.Charge++
+			}
+			return
+		} else if p.Charge == 0 {
+			return
+		} else {
+			// the kick button was released, kick
+			charge = p.Charge
+			p.Charge = 0
+		}
+		// start shooting
+		p.ShootFrames = c.ShootFrames
+		p.ShootCooldown = c.ShootCooldown
+		shootX, legsX, forward := LeftKiwiShootX, LeftKiwiLegsX, 1
+		if m.End(team) == RightSide {
+			shootX, legsX, forward = RightKiwiShootX, RightKiwiLegsX, -1
+		}
+		kick := Event{Type: Kick, Side: team, X: p.X + (shootX[0]+shootX[1])/2}
+		// check ball collision
+		if hits(p, shootX) {
+			m.kickBall(p, shootX, legsX, forward, charge)
+			kick.Speed = m.ballSpeed()
+			events = append(events, kick, Event{
+				Type:  BallHit,
+				Side:  team,
+				X:     m.ballCenter(),
+				Speed: kick.Speed,
+			})
+		} else {
+			events = append(events, kick)
+		}
+	}
+	for i := range m.Players {
+		if m.OnField(i) {
+			shoot(&m.Players[i], in.Players[i], last.Players[i], Team(i))
+		}
+	}
+
+	// move players, each kiwi can walk half way out of the field behind its own
+	// goal but only a quarter of the way out behind the other one
+	var limits [2][2]int
+	limits[LeftSide] = [2]int{-KiwiW / 2, m.Width - KiwiW/4}
+	limits[RightSide] = [2]int{-3 * KiwiW / 4, m.Width - KiwiW/2}
+	if c.KeeperZone > 0 {
+		// the foot must stay out of the other team's keeper zone
+		zone, _ := m.KeeperZone(RightSide)
+		limits[LeftSide][1] = min(limits[LeftSide][1], zone-LeftKiwiShootX[1])
+		_, zone = m.KeeperZone(LeftSide)
+		limits[RightSide][0] = max(limits[RightSide][0], zone-RightKiwiShootX[0])
+	}
+	for i := range m.Players {
+		if m.OnField(i) {
+			l := limits[m.End(Team(i))]
+			m.Players[i].move(in.Players[i], c.KiwiSpeed, l[0], l[1])
+		}
+	}
+	if c.Collisions {
+		for i := range m.Players {
+			for j := i + 1; j < len(m.Players); j++ {
+				if m.OnField(i) && m.OnField(j) {
+					a, b := &m.Players[i], &m.Players[j]
+					aEnd, bEnd := m.End(Team(i)), m.End(Team(j))
+					collideKiwis(
+						a, b, aEnd, bEnd, a.X-startX[i], b.X-startX[j],
+						limits[aEnd], limits[bEnd],
+					)
+				}
+			}
+		}
+	}
+
+	// move ball
+	m.BallRotation += m.BallVx
+	prevX, prevY := m.BallX, m.BallY
+	moveBall(c, &m.BallX, &m.BallY, &m.BallVx, &m.BallVy)
+	if c.Collisions {
+		// the ball is blocked by standing kiwis and pushed along by walking
+		// ones, which lets them dribble
+		for i := range m.Players {
+			if m.OnField(i) {
+				m.ricochet(m.Legs(i), m.Players[i].X-startX[i], prevX, prevY)
+			}
+		}
+	}
+
+	var leftGoal, rightGoal bool
+	if m.HasGoals() {
+		for _, side := range []Side{LeftSide, RightSide} {
+			// the ball passes the posts in front of or behind them, only
+			// the crossbar is in its way
+			_, crossbar := m.GoalBars(side)
+			if m.ricochet(crossbar, 0, prevX, prevY) && m.BallVy == 0 {
+				// no kiwi can reach a ball that lies on the crossbar, it
+				// rolls off to the field
+				forward := 1
+				if side == RightSide {
+					forward = -1
+				}
+				m.BallVx = forward * max(forward*m.BallVx, c.Gravity)
+			}
+		}
+		// the ball has to be completely behind the post and below the crossbar
+		box := m.ballBox()
+		inMouth := box.Y < c.GoalHeight
+		leftGoal = inMouth && box.X >= m.Width-c.GoalWidth
+		rightGoal = inMouth && box.X+box.W < c.GoalWidth
+		if !leftGoal && !rightGoal {
+			m.bounceOffWalls()
+		}
+	} else {
+		leftGoal = m.BallX+BallHitBoxX[0] >= m.Width
+		rightGoal = m.BallX+BallHitBoxX[1] < 0
+	}
+	if leftGoal || rightGoal {
+		// leftGoal is scored by the team playing from the left end
+		team := m.End(LeftSide)
+		if rightGoal {
+			team = m.End(RightSide)
+		}
+		m.Score[team]++
+		events = append(events, Event{
+			Type:  Goal,
+			Side:  team,
+			X:     m.ballCenter(),
+			Speed: m.ballSpeed(),
+		})
+		m.scoringTimer = ScoringFrames
+	}
+
+	return m.tickClock(events)
+}
+
+// kickBall gives the ball the speed of a kick in the forward direction, 1 to
+// the right or -1 to the left. The angle depends on where the foot hits the
+// ball: a ball that is only touched by the tip of the foot is kicked flat,
+// the further the foot gets under the ball, the steeper it flies, up to
+// Config.MaxKickAngle. The speed depends on the charge, see kickSpeed.
+func (m *Match) kickBall(p *Player, shootX, legsX [2]int, forward, charge int) {
+	ballHalfW := (BallHitBoxX[1] - BallHitBoxX[0]) / 2
+	ball := m.BallX + BallHitBoxX[0] + ballHalfW
+	foot := p.X + (shootX[0]+shootX[1])/2
+	reach := ballHalfW + (shootX[1]-shootX[0])/2
+	// ahead is how far the ball is in front of the foot, the ball is only hit
+	// if it is between -reach and reach
+	ahead := (ball - foot) * forward
+	// closest is where the foot is furthest under the ball, if the ball
+	// collides with the legs it cannot get closer than touching them
+	closest := -reach
+	if m.Config.Collisions {
+		front := legsX[1]
+		if forward < 0 {
+			front = legsX[0]
+		}
+		closest = (p.X + front + forward*ballHalfW - foot) * forward
+	}
+	if ahead < closest {
+		ahead = closest
+	}
+	angle := m.Config.MaxKickAngle * (reach - ahead) / (reach - closest)
+	speed := m.kickSpeed(charge)
+	m.BallVx += forward * speed * cosDeg(angle) / 1000
+	m.BallVy += speed * sinDeg(angle) / 1000
+}
+
+// moveBall advances the ball at x,y by its speed vx,vy for one frame. On the
+// ground the ball is slowed down by friction, in the air it is pulled down by
+// gravity and when it falls on the ground it bounces, losing some of its
+// speed.
+func moveBall(c *Config, x, y, vx, vy *int) {
+	*x += *vx
+	if *y > 0 || *vy != 0 {
+		*y += *vy
+		*vy -= c.Gravity
+		if *y <= 0 {
+			*y = 0
+			*vy = bounce(c, *vy)
+		}
+	} else {
+		*vx = roll(c, *vx)
+	}
+}
+
+// bounce returns the vertical speed of a ball that falls with the speed vy on
+// the ground or on top of a bar, 0 if it is too slow to get off again. The
+// speed is taken from before the last gravity step, otherwise the ball gains
+// speed in every bounce and never comes to rest.
+func bounce(c *Config, vy int) int {
+	vy = -(vy + c.Gravity) * c.BounceRestitution / 100
+	if vy <= c.Gravity {
+		return 0
+	}
+	return vy
+}
+
+// roll returns the speed of a ball rolling with the speed vx after friction
+// slowed it down for one frame.
+func roll(c *Config, vx int) int {
+	if vx > 0 {
+		return max(vx-c.BallFriction, 0)
+	}
+	return min(vx+c.BallFriction, 0)
+}
+
+// kickSpeed returns the speed of a ball that was kicked after charging for
+// the given number of frames, see Config.ChargeFrames.
+func (m *Match) kickSpeed(charge int) int {
+	c := &m.Config
+	min, max := c.MinBallShootSpeed, c.MaxBallShootSpeed
+	if c.ChargeFrames == 0 {
+		if max <= min {
+			return min
+		}
+		return min + m.rng.Intn(max-min)
+	}
+	speed := min + (max-min)*charge/c.ChargeFrames
+	if c.KickVariation > 0 {
+		speed += m.rng.Intn(2*c.KickVariation+1) - c.KickVariation
+	}
+	return speed
+}
+
+func (p *Player) cool() {
+	p.ShootFrames--
+	if p.ShootFrames < 0 {
+		p.ShootFrames = 0
+	}
+	p.ShootCooldown--
+	if p.ShootCooldown < 0 {
+		p.ShootCooldown = 0
+	}
+}
+
+func (p *Player) move(in PlayerInput, speed, minX, maxX int) {
+	if p.ShootCooldown != 0 {
+		return
+	}
+	vx := 0
+	if in.Left {
+		vx -= speed
+	}
+	if in.Right {
+		vx += speed
+	}
+	p.X += vx
+	if p.X < minX {
+		p.X = minX
+	}
+	if p.X > maxX {
+		p.X = maxX
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
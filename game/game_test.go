@@ -0,0 +1,347 @@
+package game
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBounceHeightDecays(t *testing.T) {
+	tests := []struct {
+		name        string
+		gravity     int
+		restitution int
+		y           int
+	}{
+		{"default", 2, 60, 300},
+		{"bouncy", 2, 90, 300},
+		{"dull", 2, 20, 300},
+		{"low gravity", 1, 60, 300},
+		{"high gravity", 5, 60, 300},
+		{"high drop", 2, 60, 2000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.Gravity = tt.gravity
+			c.BounceRestitution = tt.restitution
+			x, y, vx, vy := 0, tt.y, 0, 0
+			// heights has the highest point before every landing
+			heights := []int{}
+			top := y
+			for i := 0; i < 10*60 && (y > 0 || vy != 0); i++ {
+				moveBall(&c, &x, &y, &vx, &vy)
+				top = max(top, y)
+				if y == 0 {
+					heights = append(heights, top)
+					top = 0
+				}
+			}
+			if y != 0 || vy != 0 {
+				t.Fatalf("the ball still bounces at height %d with speed %d, the heights were %v",
+					y, vy, heights)
+			}
+			if len(heights) < 2 {
+				t.Fatalf("the ball did not bounce, the heights were %v", heights)
+			}
+			for i := 1; i < len(heights); i++ {
+				if heights[i] >= heights[i-1] {
+					t.Fatalf("bounce %d is not lower than the one before, the heights were %v",
+						i, heights)
+				}
+			}
+		})
+	}
+}
+
+func TestBallComesToRest(t *testing.T) {
+	tests := []struct {
+		name   string
+		y      int
+		vx, vy int
+	}{
+		{"rolling right", 0, 40, 0},
+		{"rolling left", 0, -40, 0},
+		{"rolling slower than the friction", 0, 1, 0},
+		{"falling", 200, 0, 0},
+		{"kicked up", 0, 25, 43},
+		{"kicked up to the left", 0, -43, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			x, y, vx, vy := 0, tt.y, tt.vx, tt.vy
+			for i := 0; i < 10*60; i++ {
+				moveBall(&c, &x, &y, &vx, &vy)
+			}
+			if y != 0 || vx != 0 || vy != 0 {
+				t.Errorf("the ball is at height %d with speed %d,%d after 10 seconds",
+					y, vx, vy)
+			}
+			restX := x
+			moveBall(&c, &x, &y, &vx, &vy)
+			if x != restX || y != 0 {
+				t.Errorf("the ball at rest moved from %d,0 to %d,%d", restX, x, y)
+			}
+		})
+	}
+}
+
+func TestKickAngleDependsOnHitPoint(t *testing.T) {
+	// the foot of a kiwi at 0 is at 114 when it kicks to the right and at 189
+	// when it kicks to the left, the ball's center is 29 right of its x
+	tests := []struct {
+		name       string
+		end        Side
+		collisions bool
+		// ahead is how far the ball's center is in front of the foot
+		ahead int
+		angle int
+	}{
+		{"tip of the foot", LeftSide, false, 50, 0},
+		{"center of the foot", LeftSide, false, 0, 30},
+		{"back of the foot", LeftSide, false, -50, 60},
+		{"tip of the foot to the left", RightSide, false, 51, 0},
+		{"center of the foot to the left", RightSide, false, 0, 30},
+		{"back of the foot to the left", RightSide, false, -51, 60},
+		{"tip of the foot at the legs", LeftSide, true, 50, 0},
+		{"between tip and legs", LeftSide, true, 39, 30},
+		{"touching the legs", LeftSide, true, 28, 60},
+		{"in the legs", LeftSide, true, 0, 60},
+		{"touching the legs to the left", RightSide, true, 28, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.Collisions = tt.collisions
+			m := NewMatch(1600, 1, c)
+			p := &Player{X: 500}
+			shootX, legsX, forward := LeftKiwiShootX, LeftKiwiLegsX, 1
+			if tt.end == RightSide {
+				shootX, legsX, forward = RightKiwiShootX, RightKiwiLegsX, -1
+			}
+			foot := p.X + (shootX[0]+shootX[1])/2
+			m.BallX = foot + forward*tt.ahead - 29
+			m.kickBall(p, shootX, legsX, forward, c.ChargeFrames)
+			speed := c.MaxBallShootSpeed
+			wantVx := forward * speed * cosDeg(tt.angle) / 1000
+			wantVy := speed * sinDeg(tt.angle) / 1000
+			if m.BallVx != wantVx || m.BallVy != wantVy {
+				t.Errorf("the ball flies with %d,%d but want %d,%d for %d degrees",
+					m.BallVx, m.BallVy, wantVx, wantVy, tt.angle)
+			}
+		})
+	}
+}
+
+func TestKickFromKeeperZoneScores(t *testing.T) {
+	tests := []struct {
+		name string
+		end  Side
+	}{
+		{"into the right goal", LeftSide},
+		{"into the left goal", RightSide},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			shootX, legsX, forward := LeftKiwiShootX, LeftKiwiLegsX, 1
+			if tt.end == RightSide {
+				shootX, legsX, forward = RightKiwiShootX, RightKiwiLegsX, -1
+			}
+			// the kiwi stands with its foot at the edge of the other team's
+			// keeper zone, the ball is kicked at every angle
+			left, right := NewMatch(1600, 1, c).KeeperZone(1 - tt.end)
+			x := right - shootX[0]
+			if tt.end == LeftSide {
+				x = left - shootX[1]
+			}
+			for ahead := -50; ahead <= 50; ahead++ {
+				m := NewMatch(1600, 1, c)
+				p := &m.Players[tt.end]
+				p.X = x
+				// the other kiwi stays out of the way
+				m.Players[1-tt.end].X = m.kickOffX(int(tt.end))
+				foot := p.X + (shootX[0]+shootX[1])/2
+				m.BallX = foot + forward*ahead - 29
+				m.kickBall(p, shootX, legsX, forward, c.ChargeFrames)
+				for _, e := range step(m, 3*60) {
+					if e.Type == Goal && e.Side == tt.end {
+						return
+					}
+				}
+			}
+			t.Error("no kick at the edge of the keeper zone scores")
+		})
+	}
+}
+
+func TestGoalsScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		goalWidth int
+		x, vx     int
+		team      Side
+	}{
+		{"into the right goal", 120, 1600 - 120, 10, LeftSide},
+		{"into the left goal", 120, 20, -10, RightSide},
+		{"out on the right without goals", 0, 1600 - BallHitBoxX[0] - 5, 10, LeftSide},
+		{"out on the left without goals", 0, -BallHitBoxX[1] + 5, -10, RightSide},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.GoalWidth = tt.goalWidth
+			c.KeeperZone = 0
+			m := NewMatch(1600, 1, c)
+			m.BallX, m.BallVx = tt.x, tt.vx
+			events := m.Step(Inputs{})
+			if len(events) != 1 || events[0].Type != Goal || events[0].Side != tt.team {
+				t.Fatalf("want a goal by team %d but the events are %v", tt.team, events)
+			}
+			var want [2]int
+			want[tt.team] = 1
+			if m.Score != want {
+				t.Fatalf("the score is %v but want %v", m.Score, want)
+			}
+			if m.InPlay() {
+				t.Fatal("the ball is in play right after the goal")
+			}
+			step(m, ScoringFrames)
+			if !m.InPlay() || m.BallX != (1600-BallW)/2 || m.BallVx != 0 {
+				t.Errorf("the ball was not put back in the middle, it is at %d with speed %d",
+					m.BallX, m.BallVx)
+			}
+		})
+	}
+}
+
+func TestWinningGoalEndsMatch(t *testing.T) {
+	m := NewMatch(1600, 1, DefaultConfig())
+	m.Score = [2]int{1, m.Config.WinScore - 1}
+	m.BallX, m.BallVx = 20, -10
+	step(m, 1)
+	if m.Over() {
+		t.Fatal("the match is over before the pause after the goal")
+	}
+	step(m, ScoringFrames)
+	if !m.RightWon || m.LeftWon || m.InPlay() {
+		t.Fatalf("blue won is %v, white won is %v after the winning goal",
+			m.LeftWon, m.RightWon)
+	}
+	events := step(m, m.Config.WinSoundCooldown)
+	if countEvents(events, Win) != 1 || events[0].Side != RightSide {
+		t.Errorf("want a Win event for white but got %v", events)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	kick := Inputs{}
+	kick.Players[0].Shoot = true
+
+	t.Run("running match", func(t *testing.T) {
+		m := NewMatch(1600, 1, DefaultConfig())
+		m.Score = [2]int{3, 2}
+		m.Players[0].X = 700
+		m.BallX, m.BallY, m.BallVx = 100, 50, 20
+		events := m.Step(Inputs{Restart: true})
+		if len(events) != 1 || events[0].Type != Restart {
+			t.Fatalf("want a Restart event but got %v", events)
+		}
+		fresh := NewMatch(1600, 1, DefaultConfig())
+		if m.Score != fresh.Score || m.Players[0] != fresh.Players[0] ||
+			m.BallX != fresh.BallX || m.BallY != 0 || m.BallVx != 0 {
+			t.Errorf("the match was not reset, score %v, kiwi at %d, ball at %d,%d",
+				m.Score, m.Players[0].X, m.BallX, m.BallY)
+		}
+	})
+
+	t.Run("finished match", func(t *testing.T) {
+		m := NewMatch(1600, 1, DefaultConfig())
+		m.Score = [2]int{m.Config.WinScore - 1, 0}
+		m.BallX, m.BallVx = 1600-120, 10
+		step(m, 1+ScoringFrames)
+		if !m.Over() {
+			t.Fatal("the match is not over")
+		}
+		// kicking right away does not restart the match
+		m.Step(kick)
+		m.Step(Inputs{Restart: true})
+		if !m.Over() {
+			t.Fatal("the match restarted before the restart delay")
+		}
+		for !m.CanRestart() {
+			m.Step(Inputs{})
+		}
+		if events := m.Step(kick); countEvents(events, Restart) != 1 || m.Over() {
+			t.Fatalf("kicking did not restart the match, the events are %v", events)
+		}
+		if m.Score != [2]int{} {
+			t.Errorf("the score is %v after the restart", m.Score)
+		}
+	})
+}
+
+// randomInputs returns inputs for n frames in which all kiwis walk around and
+// kick a lot.
+func randomInputs(seed int64, n int) []Inputs {
+	r := rand.New(rand.NewSource(seed))
+	inputs := make([]Inputs, n)
+	var in Inputs
+	for i := range inputs {
+		for p := range in.Players {
+			if r.Intn(20) == 0 {
+				in.Players[p].Left = r.Intn(2) == 0
+				in.Players[p].Right = !in.Players[p].Left && r.Intn(2) == 0
+			}
+			in.Players[p].Shoot = r.Intn(4) == 0
+		}
+		inputs[i] = in
+	}
+	return inputs
+}
+
+func TestCloneIsDeterministic(t *testing.T) {
+	c := DefaultConfig()
+	c.Players = MaxPlayers
+	// kicks without charging get a random speed
+	c.ChargeFrames = 0
+	inputs := randomInputs(1, 60*60)
+	m := NewMatch(1600, 7, c)
+	kicks := 0
+	for _, in := range inputs[:len(inputs)/2] {
+		kicks += countEvents(m.Step(in), BallHit)
+	}
+	if kicks == 0 {
+		t.Fatal("no kiwi hit the ball, the test does not use the random numbers")
+	}
+
+	clone := m.Clone()
+	// stepping another copy with other inputs does not change the original
+	other := m.Clone()
+	for _, in := range randomInputs(2, 600) {
+		other.Step(in)
+	}
+	if !reflect.DeepEqual(m, clone) {
+		t.Fatal("stepping a clone changed the original")
+	}
+
+	for i, in := range inputs[len(inputs)/2:] {
+		a, b := m.Step(in), clone.Step(in)
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("frame %d: the events %v of the clone differ from %v", i, b, a)
+		}
+	}
+	if !reflect.DeepEqual(m, clone) {
+		t.Error("the clone played out differently than the original")
+	}
+
+	// a new match with the same seed and inputs plays out the same, too
+	replay := NewMatch(1600, 7, c)
+	for _, in := range inputs {
+		replay.Step(in)
+	}
+	if !reflect.DeepEqual(m, replay) {
+		t.Error("the same seed and inputs played out differently")
+	}
+}
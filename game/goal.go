@@ -0,0 +1,156 @@
+package game
+
+// PostSize is the thickness of the goal posts and crossbars.
+const PostSize = 10
+
+// BallHitBoxY is the vertical part of the ball's hit box, measured as the
+// height above BallY. The ball is round so it is as high as BallHitBoxX is
+// wide.
+var BallHitBoxY = [2]int{BallH - BallHitBoxX[1], BallH - BallHitBoxX[0]}
+
+// Rect is a rectangle on the field, X goes to the right and Y is the height
+// above the ground.
+type Rect struct {
+	X, Y, W, H int
+}
+
+func (r Rect) overlaps(o Rect) bool {
+	return r.X < o.X+o.W && o.X < r.X+r.W && r.Y < o.Y+o.H && o.Y < r.Y+r.H
+}
+
+// HasGoals reports whether there are goal frames at the ends of the field. If
+// Config.GoalWidth is 0 there are none and the whole end of the field counts
+// as the goal, like in the original game.
+func (m *Match) HasGoals() bool {
+	return m.Config.GoalWidth > 0
+}
+
+// GoalBars returns the post and the crossbar of the goal on the given side of
+// the field. The LeftSide goal is defended by the team at the left end. The
+// goal mouth is behind the post, between the ground and the crossbar. The
+// field is seen from the side, so the ball passes the post in front of or
+// behind it, only the crossbar and its front end block the ball.
+func (m *Match) GoalBars(side Side) (post, crossbar Rect) {
+	c := &m.Config
+	post = Rect{X: c.GoalWidth, Y: 0, W: PostSize, H: c.GoalHeight}
+	crossbar = Rect{X: 0, Y: c.GoalHeight, W: c.GoalWidth + PostSize, H: PostSize}
+	if side == RightSide {
+		post.X = m.Width - c.GoalWidth - PostSize
+		crossbar.X = m.Width - c.GoalWidth - PostSize
+	}
+	return
+}
+
+// KeeperZone returns the left and right end of the zone in front of the goal
+// on the given side. Only the team defending that goal may step into it. If
+// Config.KeeperZone is 0, the zone is empty.
+func (m *Match) KeeperZone(side Side) (left, right int) {
+	c := &m.Config
+	if side == LeftSide {
+		return c.GoalWidth, c.GoalWidth + c.KeeperZone
+	}
+	return m.Width - c.GoalWidth - c.KeeperZone, m.Width - c.GoalWidth
+}
+
+func (m *Match) ballBox() Rect {
+	return Rect{
+		X: m.BallX + BallHitBoxX[0],
+		Y: m.BallY + BallHitBoxY[0],
+		W: BallHitBoxX[1] - BallHitBoxX[0],
+		H: BallHitBoxY[1] - BallHitBoxY[0],
+	}
+}
+
+// ricochet bounces the ball off the bar if it hit it during this frame. The
+// ball was at prevX,prevY before it moved, the bar moved by barVx.
+func (m *Match) ricochet(bar Rect, barVx, prevX, prevY int) bool {
+	now := m.ballBox()
+	before := now
+	// look at the ball as seen from the bar, as if the bar stood still
+	before.X += prevX + barVx - m.BallX
+	before.Y += prevY - m.BallY
+	// a fast ball could fly through the bar between two frames, so we look at
+	// all the space that the ball went through
+	swept := Rect{
+		X: min(now.X, before.X),
+		Y: min(now.Y, before.Y),
+		W: abs(now.X-before.X) + now.W,
+		H: abs(now.Y-before.Y) + now.H,
+	}
+	// a ball that falls exactly onto the bar or lies on it touches it, just
+	// like the ground, see moveBall
+	landed := m.BallVy < 0 && now.Y == bar.Y+bar.H && before.Y >= now.Y &&
+		now.X < bar.X+bar.W && bar.X < now.X+now.W
+	if !swept.overlaps(bar) && !landed {
+		return false
+	}
+	restitution := m.Config.BounceRestitution
+	switch {
+	case before.X+before.W <= bar.X:
+		m.BallX = bar.X - now.W - BallHitBoxX[0]
+		m.BallVx = barVx - abs(m.BallVx-barVx)*restitution/100
+	case before.X >= bar.X+bar.W:
+		m.BallX = bar.X + bar.W - BallHitBoxX[0]
+		m.BallVx = barVx + abs(m.BallVx-barVx)*restitution/100
+	case before.Y >= bar.Y+bar.H:
+		m.BallY = bar.Y + bar.H - BallHitBoxY[0]
+		// like on the ground, see moveBall
+		m.BallVy = bounce(&m.Config, m.BallVy)
+		if m.BallVy == 0 {
+			// lying on the bar, it rolls along with the bar
+			m.BallVx = barVx + roll(&m.Config, m.BallVx-barVx)
+		}
+		// the ball rolls off bars that are narrower than the ball and off
+		// the ends of bars when its middle is not over them
+		middle := now.X + now.W/2
+		if bar.W < now.W || middle < bar.X || middle >= bar.X+bar.W {
+			if middle < bar.X+bar.W/2 {
+				m.BallVx = min(m.BallVx, barVx-m.Config.Gravity)
+			} else {
+				m.BallVx = max(m.BallVx, barVx+m.Config.Gravity)
+			}
+		}
+	case before.Y+before.H <= bar.Y:
+		m.BallY = bar.Y - now.H - BallHitBoxY[0]
+		m.BallVy = -abs(m.BallVy) * restitution / 100
+	default:
+		// the ball was already stuck in the bar, e.g. when it was pushed
+		// against a wall, move it out to the closer side
+		if now.X+now.W/2 < bar.X+bar.W/2 {
+			m.BallX = bar.X - now.W - BallHitBoxX[0]
+		} else {
+			m.BallX = bar.X + bar.W - BallHitBoxX[0]
+		}
+		m.BallVx = barVx
+	}
+	return true
+}
+
+// bounceOffWalls keeps the ball inside the field, the ends of the field are
+// the back of the goals and the walls above them.
+func (m *Match) bounceOffWalls() {
+	box := m.ballBox()
+	restitution := m.Config.BounceRestitution
+	if box.X < 0 {
+		m.BallX = -BallHitBoxX[0]
+		m.BallVx = abs(m.BallVx) * restitution / 100
+	}
+	if box.X+box.W > m.Width {
+		m.BallX = m.Width - BallHitBoxX[1]
+		m.BallVx = -abs(m.BallVx) * restitution / 100
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
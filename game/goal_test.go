@@ -0,0 +1,70 @@
+package game
+
+import "testing"
+
+func TestBallRollsOffCrossbar(t *testing.T) {
+	tests := []struct {
+		name   string
+		x, y   int
+		vx, vy int
+	}{
+		{"lying on the left crossbar", 40, 182, 0, 0},
+		{"falling on the left crossbar at the wall", -7, 230, 0, 0},
+		{"lying on the right crossbar", 1600 - 40 - BallW, 182, 0, 0},
+		{"rolling into the wall on the crossbar", 20, 182, -5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch(1600, 1, DefaultConfig())
+			m.BallX, m.BallY, m.BallVx, m.BallVy = tt.x, tt.y, tt.vx, tt.vy
+			for i := 0; i < 10*60; i++ {
+				m.Step(Inputs{})
+				if m.BallY == 0 {
+					return
+				}
+			}
+			t.Errorf("the ball is still in the air at %d,%d with speed %d,%d",
+				m.BallX, m.BallY, m.BallVx, m.BallVy)
+		})
+	}
+}
+
+func TestBallScoresPastThePost(t *testing.T) {
+	tests := []struct {
+		name   string
+		x, y   int
+		vx, vy int
+		team   Side
+	}{
+		// a ball has to roll faster than a kick to reach a goal from midfield
+		{"rolling into the right goal", 770, 0, 75, 0, LeftSide},
+		{"rolling into the left goal", 770, 0, -75, 0, RightSide},
+		{"rolling in slowly", 1380, 0, 25, 0, LeftSide},
+		{"flat kick", 770, 0, 48, 10, LeftSide},
+		{"flat kick to the left", 770, 0, -48, 10, RightSide},
+		{"bouncing in", 770, 100, 40, 0, LeftSide},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch(1600, 1, DefaultConfig())
+			// the kiwis stand at the other end, out of the ball's way
+			for i := range m.Players {
+				m.Players[i].X = 0
+				if tt.team == RightSide {
+					m.Players[i].X = 1600 - KiwiW
+				}
+			}
+			m.BallX, m.BallY, m.BallVx, m.BallVy = tt.x, tt.y, tt.vx, tt.vy
+			for i := 0; i < 10*60; i++ {
+				if countEvents(m.Step(Inputs{}), Goal) == 1 {
+					if m.Score[tt.team] != 1 {
+						t.Fatalf("the wrong team scored, the score is %v", m.Score)
+					}
+					return
+				}
+			}
+			t.Errorf("no goal, the ball is at %d,%d with speed %d,%d",
+				m.BallX, m.BallY, m.BallVx, m.BallVy)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package game
+
+// source is a splitmix64 random number generator for math/rand. Its whole
+// state is a single number, unlike that of rand.NewSource, which makes it
+// possible to copy a Match, see Match.Clone.
+type source struct {
+	state uint64
+}
+
+func (s *source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+func (s *source) Uint64() uint64 {
+	s.state += 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+func (s *source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
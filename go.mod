@@ -4,6 +4,9 @@ go 1.19
 
 require (
 	github.com/gonutz/di8 v1.0.0
+	github.com/gonutz/glfw v1.0.2
+	github.com/gonutz/go-sdl2 v1.0.0
+	github.com/gonutz/mixer v1.0.0
 	github.com/gonutz/prototype v1.1.1
 	github.com/gonutz/w32 v1.0.0
 )
@@ -13,8 +16,5 @@ require (
 	github.com/gonutz/d3d9 v1.2.1 // indirect
 	github.com/gonutz/ds v1.0.0 // indirect
 	github.com/gonutz/gl v1.0.0 // indirect
-	github.com/gonutz/glfw v1.0.2 // indirect
-	github.com/gonutz/go-sdl2 v1.0.0 // indirect
-	github.com/gonutz/mixer v1.0.0 // indirect
 	github.com/gonutz/w32/v2 v2.2.0 // indirect
 )
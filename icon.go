@@ -0,0 +1,51 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+)
+
+// On Windows the icon is a resource in the .exe, on other platforms it is
+// embedded and set on the window after it was created.
+//
+//go:embed icon.ico
+var iconICO []byte
+
+// loadIcon returns the largest PNG image in icon.ico.
+func loadIcon() (image.Image, error) {
+	ico := iconICO
+	if len(ico) < 6 {
+		return nil, errors.New("icon.ico is too short")
+	}
+	count := int(binary.LittleEndian.Uint16(ico[4:]))
+	var best image.Image
+	for i := 0; i < count; i++ {
+		entry := ico[6+16*i:]
+		if len(entry) < 16 {
+			break
+		}
+		size := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		if int(offset+size) > len(ico) {
+			continue
+		}
+		img, err := png.Decode(bytes.NewReader(ico[offset : offset+size]))
+		if err != nil {
+			// this entry is a bitmap, not a PNG
+			continue
+		}
+		if best == nil || img.Bounds().Dx() > best.Bounds().Dx() {
+			best = img
+		}
+	}
+	if best == nil {
+		return nil, errors.New("icon.ico contains no PNG image")
+	}
+	return best, nil
+}
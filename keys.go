@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gonutz/jolina/draw"
+	"github.com/gonutz/jolina/game"
+)
+
+// playerKeys are the keys that control one kiwi.
+type playerKeys struct {
+	Kick  draw.Key
+	Left  draw.Key
+	Right draw.Key
+}
+
+// keyBindings are the keyboard controls for all kiwis by their player
+// numbers, see game.Team.
+type keyBindings [game.MaxPlayers]playerKeys
+
+func defaultKeyBindings() keyBindings {
+	return keyBindings{
+		{Kick: draw.KeyW, Left: draw.KeyA, Right: draw.KeyD},
+		{Kick: draw.KeyUp, Left: draw.KeyLeft, Right: draw.KeyRight},
+		{Kick: draw.KeyI, Left: draw.KeyJ, Right: draw.KeyL},
+		{Kick: draw.KeyNum8, Left: draw.KeyNum4, Right: draw.KeyNum6},
+	}
+}
+
+// playerNames name the kiwis by their player numbers, in the key bindings
+// file and as the prefix of the message IDs for their keys.
+var playerNames = [game.MaxPlayers]string{"blue", "white", "blue2", "white2"}
+
+// binding is one rebindable action, see keyBindings.list. The name is a
+// message ID, see tr.
+type binding struct {
+	name string
+	key  *draw.Key
+}
+
+// list returns the bindings of the given number of players in the order in
+// which they are rebound.
+func (k *keyBindings) list(players int) []binding {
+	var list []binding
+	for i := 0; i < players; i++ {
+		name := playerNames[i]
+		list = append(list,
+			binding{name + "Kick", &k[i].Kick},
+			binding{name + "Left", &k[i].Left},
+			binding{name + "Right", &k[i].Right},
+		)
+	}
+	return list
+}
+
+// reservedKeys cannot be bound to a kiwi, they are used for the menus.
+var reservedKeys = []draw.Key{draw.KeyEscape, draw.KeyEnter, draw.KeySpace, rebindKey, fullscreenKey, screenshotKey}
+
+const (
+	// rebindKey opens the screen for changing the key bindings.
+	rebindKey = draw.KeyF1
+	// fullscreenKey switches between fullscreen and the window.
+	fullscreenKey = draw.KeyF11
+	// screenshotKey saves a picture of the match.
+	screenshotKey = draw.KeyF12
+)
+
+// keyBindingsPath is the per-user file that the key bindings are saved in.
+func keyBindingsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "jolina", "keys.json")
+}
+
+// The keys are stored by name in the JSON file, by the playerNames, e.g.
+//
+//	{"blue": {"kick": "W", "left": "A", "right": "D"}, ...}
+type keyNamesJSON map[string]map[string]string
+
+// loadKeyBindings reads the saved key bindings, if there are none the default
+// bindings are returned.
+func loadKeyBindings(path string) (keyBindings, error) {
+	keys := defaultKeyBindings()
+	if path == "" {
+		return keys, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return keys, err
+	}
+	var names keyNamesJSON
+	if err := json.Unmarshal(data, &names); err != nil {
+		return keys, fmt.Errorf("%s: %v", path, err)
+	}
+	for i := range keys {
+		p := &keys[i]
+		for action, key := range map[string]*draw.Key{
+			"kick":  &p.Kick,
+			"left":  &p.Left,
+			"right": &p.Right,
+		} {
+			if name, ok := names[playerNames[i]][action]; ok {
+				k, ok := keyByName(name)
+				if !ok {
+					return defaultKeyBindings(), fmt.Errorf("%s: unknown key '%s'", path, name)
+				}
+				*key = k
+			}
+		}
+	}
+	return keys, nil
+}
+
+func saveKeyBindings(path string, keys keyBindings) error {
+	if path == "" {
+		return errors.New("no user config directory to save the keys in")
+	}
+	names := keyNamesJSON{}
+	for i, p := range keys {
+		names[playerNames[i]] = map[string]string{
+			"kick":  p.Kick.String(),
+			"left":  p.Left.String(),
+			"right": p.Right.String(),
+		}
+	}
+	data, err := json.MarshalIndent(names, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// allKeys are all keys known to the draw package. It has no exported key
+// count so we go through the keys until their names become unknown.
+var allKeys = func() []draw.Key {
+	var keys []draw.Key
+	for k := draw.KeyA; !strings.HasPrefix(k.String(), "Unknown"); k++ {
+		keys = append(keys, k)
+	}
+	return keys
+}()
+
+func keyByName(name string) (draw.Key, bool) {
+	for _, k := range allKeys {
+		if strings.EqualFold(k.String(), name) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// rebinder is the screen for changing the key bindings of the given number of
+// players. It asks for one key after the other, pressing Escape cancels and
+// keeps the old bindings.
+type rebinder struct {
+	keys    keyBindings
+	players int
+	current int
+}
+
+func newRebinder(keys keyBindings, players int) *rebinder {
+	return &rebinder{keys: keys, players: players}
+}
+
+// update captures the next pressed key. It returns true when all keys are
+// bound and the new bindings are in r.keys or when the player cancelled, in
+// which case ok is false.
+func (r *rebinder) update(window draw.Window) (done, ok bool) {
+	if window.WasKeyPressed(draw.KeyEscape) {
+		return true, false
+	}
+	for _, k := range allKeys {
+		if !window.WasKeyPressed(k) || isReserved(k) {
+			continue
+		}
+		list := r.keys.list(r.players)
+		taken := false
+		for _, b := range list[:r.current] {
+			taken = taken || *b.key == k
+		}
+		if taken {
+			continue
+		}
+		*list[r.current].key = k
+		r.current++
+		return r.current == len(list), true
+	}
+	return false, true
+}
+
+func isReserved(k draw.Key) bool {
+	for _, r := range reservedKeys {
+		if k == r {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rebinder) draw(window draw.Window) {
+	w, h := window.Size()
+	window.FillRect(0, 0, w, h, draw.RGBA(0, 0, 0, 0.7))
+	const scale = 2
+	x, y := 20, 20
+	text := func(s string, color draw.Color) {
+		_, textH := window.GetScaledTextSize(s, scale)
+		if y+textH > h {
+			// the keys for four kiwis do not fit below each other
+			x, y = w/2, 20+textH+10
+		}
+		window.DrawScaledText(s, x, y, scale, color)
+		y += textH + 10
+	}
+	text(tr("rebindTitle"), draw.White)
+	for i, b := range r.keys.list(r.players) {
+		line := tr(b.name) + ": " + b.key.String()
+		color := draw.Gray
+		if i == r.current {
+			line = tr(b.name) + ": " + tr("pressKey")
+			color = draw.Yellow
+		} else if i < r.current {
+			color = draw.White
+		}
+		text(line, color)
+	}
+}
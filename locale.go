@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// languages are the message catalogs in rsc/lang. The first one is used for
+// languages without a catalog and for messages that are missing in another
+// catalog.
+var languages = []string{"en", "de"}
+
+var (
+	messages         map[string]string
+	fallbackMessages map[string]string
+)
+
+// setLanguage loads the message catalog for the given language, e.g. "de".
+// An empty language uses the language of the operating system.
+func setLanguage(lang string) error {
+	if lang == "" {
+		lang = systemLanguage()
+		if !hasLanguage(lang) {
+			lang = languages[0]
+		}
+	}
+	if !hasLanguage(lang) {
+		return errors.New("unknown language '" + lang + "', use " + strings.Join(languages, " or "))
+	}
+	var err error
+	fallbackMessages, err = loadMessages(languages[0])
+	if err != nil {
+		return err
+	}
+	messages, err = loadMessages(lang)
+	return err
+}
+
+func hasLanguage(lang string) bool {
+	for _, l := range languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func loadMessages(lang string) (map[string]string, error) {
+	data, err := rsc.ReadFile("rsc/lang/" + lang + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("rsc/lang/%s.json: %v", lang, err)
+	}
+	return m, nil
+}
+
+// tr returns the message with the given ID in the current language. If there
+// are args, the message is used as their format string.
+func tr(id string, args ...interface{}) string {
+	s, ok := messages[id]
+	if !ok {
+		s, ok = fallbackMessages[id]
+	}
+	if !ok {
+		s = id
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// languageCode returns the two letter language of a locale name like
+// "de_DE.UTF-8" or "en-US".
+func languageCode(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "_-.@"); i != -1 {
+		locale = locale[:i]
+	}
+	return locale
+}
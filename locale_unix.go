@@ -0,0 +1,22 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// systemLanguage returns the language code of the user's locale, following
+// the same environment variables as gettext.
+func systemLanguage() string {
+	if list := os.Getenv("LANGUAGE"); list != "" {
+		return languageCode(strings.Split(list, ":")[0])
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(env); locale != "" {
+			return languageCode(locale)
+		}
+	}
+	return ""
+}
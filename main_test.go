@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gonutz/jolina/draw"
+	"github.com/gonutz/jolina/game"
+	"github.com/gonutz/jolina/offscreen"
+	"github.com/gonutz/jolina/pad"
+)
+
+func TestReadInputs(t *testing.T) {
+	right := game.PlayerInput{Right: true}
+	tests := []struct {
+		name    string
+		keys    []draw.Key
+		pads    []pad.State
+		players int
+		// ai is the player number of a computer kiwi, -1 for none
+		ai   int
+		want [game.MaxPlayers]game.PlayerInput
+	}{
+		{"no input", nil, []pad.State{{}, {}}, 2, -1, [4]game.PlayerInput{}},
+		{"pad walks right", nil, []pad.State{{X: 1}}, 2, -1, [4]game.PlayerInput{right}},
+		{"pad walks left", nil, []pad.State{{X: -1}}, 2, -1, [4]game.PlayerInput{{Left: true}}},
+		{"small tilt", nil, []pad.State{{X: 0.5, Y: 1}}, 2, -1, [4]game.PlayerInput{}},
+		{"any button kicks", nil, []pad.State{{Buttons: 1 << 3}}, 2, -1, [4]game.PlayerInput{{Shoot: true}}},
+		{"start does not kick", nil, []pad.State{{Buttons: pad.StartButton}}, 2, -1, [4]game.PlayerInput{}},
+		{"second pad", nil, []pad.State{{}, {X: 1}}, 2, -1, [4]game.PlayerInput{{}, right}},
+		{"more pads than kiwis", nil, []pad.State{{}, {}, {X: 1}}, 2, -1, [4]game.PlayerInput{}},
+		{"four kiwis", nil, []pad.State{{}, {}, {}, {X: 1}}, 4, -1, [4]game.PlayerInput{{}, {}, {}, right}},
+		{"pads skip the computer's kiwis", nil, []pad.State{{X: 1}}, 2, 0, [4]game.PlayerInput{{}, right}},
+		{"keys and pad", []draw.Key{draw.KeyW}, []pad.State{{X: 1}}, 2, -1, [4]game.PlayerInput{{Shoot: true, Right: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := offscreen.New(1, 1)
+			for _, key := range tt.keys {
+				window.SetKeyDown(key, true)
+			}
+			var ais []*game.AI
+			if tt.ai != -1 {
+				ais = append(ais, game.NewAI(tt.ai, game.Medium, 1))
+			}
+			pads := &pad.Fake{States: tt.pads}
+			in := readInputs(window, defaultKeyBindings(), pads.Poll(), tt.players, ais)
+			if in.Players != tt.want {
+				t.Errorf("the inputs are %v but want %v", in.Players, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"github.com/gonutz/jolina/draw"
+	"github.com/gonutz/jolina/game"
+	"github.com/gonutz/jolina/pad"
+)
+
+// menuInput is what the players did in a menu during one frame, combined from
+// the keyboard, the game pads and the mouse.
+type menuInput struct {
+	up, down, left, right bool
+	// confirm is Enter, Space or any pad button but Start, back is Escape or
+	// the right mouse button
+	confirm, back bool
+	// pause is the Start button on a pad
+	pause  bool
+	clicks []draw.MouseClick
+}
+
+func (a *app) readMenuInput(window draw.Window) menuInput {
+	keys := func(keys ...draw.Key) bool {
+		for _, k := range keys {
+			if window.WasKeyPressed(k) {
+				return true
+			}
+		}
+		return false
+	}
+	in := menuInput{
+		up:      keys(draw.KeyUp, draw.KeyW),
+		down:    keys(draw.KeyDown, draw.KeyS),
+		left:    keys(draw.KeyLeft, a.keys[0].Left, a.keys[1].Left),
+		right:   keys(draw.KeyRight, a.keys[0].Right, a.keys[1].Right),
+		confirm: keys(draw.KeyEnter, draw.KeySpace),
+		back:    keys(draw.KeyEscape),
+		clicks:  window.Clicks(),
+	}
+	for _, c := range in.clicks {
+		in.back = in.back || c.Button == draw.RightButton
+	}
+	// a pad axis counts once when it is pushed over the threshold
+	pushed := func(now, before, dir float64) bool {
+		return now*dir > 0.5 && before*dir <= 0.5
+	}
+	for i, p := range a.padStates {
+		var before pad.State
+		if i < len(a.prevPads) {
+			before = a.prevPads[i]
+		}
+		in.up = in.up || pushed(p.Y, before.Y, -1)
+		in.down = in.down || pushed(p.Y, before.Y, 1)
+		in.left = in.left || pushed(p.X, before.X, -1)
+		in.right = in.right || pushed(p.X, before.X, 1)
+		in.confirm = in.confirm || p.Pressed&^pad.StartButton != 0
+		in.pause = in.pause || p.Pressed&pad.StartButton != 0
+	}
+	return in
+}
+
+// menuItem is one line in a menu.
+type menuItem struct {
+	text string
+	// activate is called when the item is chosen, it returns the next scene
+	activate func() scene
+	// change is called with -1 or 1 when left or right is pressed on the item
+	// or with 1 when it is clicked, it is nil for items without a value
+	change func(dir int)
+}
+
+// menu is a vertical list of items, one of which is selected.
+type menu struct {
+	selected int
+	// mouseX and mouseY are used to select items only when the mouse moves,
+	// otherwise it would keep overriding the keyboard
+	mouseX, mouseY int
+	// boxes are where the items were drawn in the last frame
+	boxes []rect
+}
+
+type rect struct {
+	x, y, w, h int
+}
+
+func (r rect) contains(x, y int) bool {
+	return r.x <= x && x < r.x+r.w && r.y <= y && y < r.y+r.h
+}
+
+// update changes the selection and values of the items. It returns the index
+// of the activated item or -1.
+func (m *menu) update(window draw.Window, in menuInput, items []menuItem) int {
+	if in.up {
+		m.selected = (m.selected + len(items) - 1) % len(items)
+	}
+	if in.down {
+		m.selected = (m.selected + 1) % len(items)
+	}
+	if x, y := window.MousePosition(); x != m.mouseX || y != m.mouseY {
+		m.mouseX, m.mouseY = x, y
+		if i := m.itemAt(x, y); i != -1 {
+			m.selected = i
+		}
+	}
+	if m.selected >= len(items) {
+		m.selected = 0
+	}
+
+	item := items[m.selected]
+	if item.change != nil {
+		if in.left {
+			item.change(-1)
+		}
+		if in.right {
+			item.change(1)
+		}
+	}
+	for _, c := range in.clicks {
+		if i := m.itemAt(c.X, c.Y); c.Button == draw.LeftButton && i != -1 {
+			m.selected = i
+			if items[i].change != nil {
+				items[i].change(1)
+			} else {
+				return i
+			}
+		}
+	}
+	if in.confirm {
+		if item.change != nil {
+			item.change(1)
+		} else {
+			return m.selected
+		}
+	}
+	return -1
+}
+
+func (m *menu) itemAt(x, y int) int {
+	for i, box := range m.boxes {
+		if box.contains(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// draw draws the items below each other, starting at x,y.
+func (m *menu) draw(window draw.Window, items []menuItem, x, y int) {
+	const scale = 2
+	m.boxes = m.boxes[:0]
+	for i, item := range items {
+		text := item.text
+		color := draw.Black
+		if item.change != nil {
+			text = "< " + text + " >"
+		}
+		if i == m.selected {
+			color = draw.DarkBlue
+			text = "> " + text
+		} else {
+			text = "  " + text
+		}
+		w, h := window.GetScaledTextSize(text, scale)
+		window.DrawScaledText(text, x, y, scale, color)
+		m.boxes = append(m.boxes, rect{x: x, y: y, w: w, h: h})
+		y += h + 10
+	}
+}
+
+// drawBackground draws the grass with a title at the top and returns the y
+// below the title.
+func drawBackground(window draw.Window, title string) int {
+	fieldW, _ := window.Size()
+	window.FillRect(0, 0, fieldW, fieldH, draw.LightGreen)
+	const scale = 3
+	w, h := window.GetScaledTextSize(title, scale)
+	window.DrawScaledText(title, (fieldW-w)/2, 20, scale, draw.Black)
+	return 20 + h + 30
+}
+
+// titleScene is the first screen after starting the game.
+type titleScene struct {
+	app        *app
+	blinkTimer int
+	blinking   bool
+}
+
+func newTitleScene(a *app) *titleScene {
+	return &titleScene{app: a}
+}
+
+func (s *titleScene) update(window draw.Window, in menuInput) scene {
+	if in.back {
+		return nil
+	}
+	clicked := false
+	for _, c := range in.clicks {
+		clicked = clicked || c.Button == draw.LeftButton
+	}
+	if in.confirm || clicked {
+		return newModeScene(s.app)
+	}
+
+	fieldW, _ := window.Size()
+	window.FillRect(0, 0, fieldW, fieldH, draw.LightGreen)
+	const titleScale = 5
+	title := tr("title")
+	titleW, titleH := window.GetScaledTextSize(title, titleScale)
+	window.DrawScaledText(title, (fieldW-titleW)/2, 40, titleScale, draw.Black)
+	y := fieldH - game.KiwiH - 20
+	window.DrawImageFile(leftKiwiPath, fieldW/2-game.KiwiW-game.BallW, y)
+	window.DrawImageFile(ballPath, (fieldW-game.BallW)/2, fieldH-game.BallH-10)
+	window.DrawImageFile(rightKiwiPath, fieldW/2+game.BallW, fieldH-game.KiwiH)
+
+	s.blinkTimer -= s.app.ticks
+	if s.blinkTimer < 0 {
+		s.blinkTimer = s.app.settings.BlinkCooldown
+		s.blinking = !s.blinking
+	}
+	if s.blinking {
+		const scale = 2
+		text := tr("pressToStart")
+		w, _ := window.GetScaledTextSize(text, scale)
+		window.DrawScaledText(text, (fieldW-w)/2, 40+titleH+20, scale, draw.Black)
+	}
+	return s
+}
+
+// modeScene is the main menu where the kind of match is chosen.
+type modeScene struct {
+	app  *app
+	menu menu
+}
+
+func newModeScene(a *app) *modeScene {
+	return &modeScene{app: a}
+}
+
+func (s *modeScene) update(window draw.Window, in menuInput) scene {
+	if in.back {
+		return newTitleScene(s.app)
+	}
+	a := s.app
+	vsComputer, players := tr("vsComputer"), tr("twoPlayers")
+	if a.settings.Players == game.MaxPlayers {
+		vsComputer, players = tr("teamVsComputer"), tr("fourPlayers")
+	}
+	items := []menuItem{
+		{text: vsComputer, activate: func() scene { return a.newMatch(vsAI) }},
+		{text: players, activate: func() scene { return a.newMatch(twoPlayers) }},
+		{text: tr("practice"), activate: func() scene { return a.newMatch(practice) }},
+		{text: tr("settings"), activate: func() scene { return newOptionsScene(a, s) }},
+		{text: tr("quit"), activate: func() scene { return nil }},
+	}
+	if i := s.menu.update(window, in, items); i != -1 {
+		return items[i].activate()
+	}
+	y := drawBackground(window, tr("modeTitle"))
+	s.menu.draw(window, items, 40, y)
+	return s
+}
+
+// difficultyNames are the message IDs for the game.Difficulty values.
+var difficultyNames = [...]string{
+	game.Easy:   "easy",
+	game.Medium: "medium",
+	game.Hard:   "hard",
+}
+
+// maxMenuWinScore is the highest win score that can be chosen in the options,
+// higher scores can still be set in the config file.
+const maxMenuWinScore = 30
+
+// maxMenuHalfMinutes is the longest half in minutes that can be chosen in the
+// options, other lengths can be set in the config file.
+const maxMenuHalfMinutes = 10
+
+// framesPerMinute converts the half length between minutes and frames.
+const framesPerMinute = 60 * 60
+
+// onOff returns the message ID for a boolean option.
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// changeVolume changes a volume in percent by 10 in the given direction, it
+// wraps around from 100 to 0 and back.
+func changeVolume(volume *int, dir int) {
+	*volume = (*volume/10*10 + 10*dir + 110) % 110
+}
+
+// halfLength is the text for the half length option, matches without halves
+// are played up to the win score.
+func halfLength(frames int) string {
+	if frames == 0 {
+		return tr("off")
+	}
+	seconds := frames / 60
+	if seconds%60 == 0 {
+		return tr("halfMinutes", seconds/60)
+	}
+	return tr("halfSeconds", seconds)
+}
+
+// optionsScene lets the players change the settings for the next matches.
+type optionsScene struct {
+	app  *app
+	back scene
+	// match is the match that the settings were opened from in the pause
+	// menu, it keeps running if it is a network match
+	match     *matchScene
+	menu      menu
+	rebinding *rebinder
+}
+
+func newOptionsScene(a *app, back scene) *optionsScene {
+	return &optionsScene{app: a, back: back}
+}
+
+func (s *optionsScene) update(window draw.Window, in menuInput) scene {
+	a := s.app
+	if s.match != nil {
+		s.match.step(window)
+	}
+	if s.rebinding != nil {
+		if done, ok := s.rebinding.update(window); done {
+			if ok {
+				a.setKeys(s.rebinding.keys)
+			}
+			s.rebinding = nil
+		}
+	} else if in.back {
+		return s.back
+	}
+
+	aiKiwi := "white"
+	if a.aiSide == game.LeftSide {
+		aiKiwi = "blue"
+	}
+	items := []menuItem{
+		{
+			text: tr("players", a.settings.Players),
+			change: func(int) {
+				if a.settings.Players == 2 {
+					a.settings.Players = game.MaxPlayers
+				} else {
+					a.settings.Players = 2
+				}
+			},
+		},
+		{
+			text: tr("difficulty", tr(difficultyNames[a.difficulty])),
+			change: func(dir int) {
+				n := len(difficultyNames)
+				a.difficulty = game.Difficulty((int(a.difficulty) + dir + n) % n)
+			},
+		},
+		{
+			text: tr("computerPlays", tr(aiKiwi)),
+			change: func(int) {
+				if a.aiSide == game.LeftSide {
+					a.aiSide = game.RightSide
+				} else {
+					a.aiSide = game.LeftSide
+				}
+			},
+		},
+		{
+			text: tr("winScore", a.settings.WinScore),
+			change: func(dir int) {
+				a.settings.WinScore += dir
+				if a.settings.WinScore < 1 {
+					a.settings.WinScore = maxMenuWinScore
+				}
+				if a.settings.WinScore > maxMenuWinScore {
+					a.settings.WinScore = 1
+				}
+			},
+		},
+		{
+			text: tr("halves", halfLength(a.settings.HalfFrames)),
+			change: func(dir int) {
+				n := maxMenuHalfMinutes + 1
+				minutes := a.settings.HalfFrames / framesPerMinute
+				a.settings.HalfFrames = (minutes + dir + n) % n * framesPerMinute
+			},
+		},
+		{
+			text:   tr("musicVolume", a.settings.MusicVolume),
+			change: func(dir int) { changeVolume(&a.settings.MusicVolume, dir) },
+		},
+		{
+			text:   tr("effectsVolume", a.settings.EffectsVolume),
+			change: func(dir int) { changeVolume(&a.settings.EffectsVolume, dir) },
+		},
+		{
+			text: tr("fullscreen", tr(onOff(a.fullscreen))),
+			change: func(int) {
+				a.setFullscreen(window, !a.fullscreen)
+			},
+		},
+		{text: tr("changeKeys"), activate: func() scene {
+			s.rebinding = newRebinder(a.keys, a.settings.Players)
+			return s
+		}},
+		{text: tr("back"), activate: func() scene { return s.back }},
+	}
+	if s.rebinding == nil {
+		if i := s.menu.update(window, in, items); i != -1 {
+			if next := items[i].activate(); next != s {
+				return next
+			}
+		}
+	}
+
+	y := drawBackground(window, tr("settings"))
+	s.menu.draw(window, items, 40, y)
+	if s.rebinding != nil {
+		s.rebinding.draw(window)
+	}
+	return s
+}
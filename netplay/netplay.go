@@ -0,0 +1,470 @@
+// Package netplay lets two computers play a match against each other over UDP.
+//
+// Each side only sends the inputs of its own kiwi. Local inputs are delayed by
+// a few frames before they are applied, which hides most of the network
+// latency. If the other side's inputs for a frame have not arrived in time,
+// they are predicted and once the real inputs arrive the match is rolled back
+// to the last frame that is known for sure and simulated again.
+//
+// The host plays the blue kiwi on the left, the joining side plays the white
+// kiwi on the right. The host decides the seed, field width and game config.
+package netplay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/gonutz/jolina/game"
+)
+
+const (
+	helloPacket = 1 + iota
+	startPacket
+	inputsPacket
+)
+
+const (
+	// maxPrediction is the number of frames that the local side may run ahead
+	// of the last known remote input, after that the match waits.
+	maxPrediction = 30
+	// maxResend is the maximum number of unacknowledged inputs sent in one
+	// packet.
+	maxResend = 120
+	// syncInterval is the minimum number of frames between two frames that
+	// are skipped to let the other side catch up.
+	syncInterval = 6
+	// joinTimeout is how long Join waits for the host to answer.
+	joinTimeout = 10 * time.Second
+)
+
+// Options change the behavior of a Session. Loss and Latency simulate a bad
+// network for testing, they are applied to every packet that is sent.
+type Options struct {
+	// Delay is the number of frames that local inputs are delayed. The host's
+	// Delay is used by both sides.
+	Delay int
+	// Loss is the probability between 0 and 1 that a packet is dropped.
+	Loss float64
+	// Latency is added to every sent packet.
+	Latency time.Duration
+}
+
+// input is what one side sends per frame.
+type input struct {
+	game.PlayerInput
+	Restart bool
+}
+
+func (in input) encode() byte {
+	var b byte
+	if in.Shoot {
+		b |= 1
+	}
+	if in.Left {
+		b |= 2
+	}
+	if in.Right {
+		b |= 4
+	}
+	if in.Restart {
+		b |= 8
+	}
+	return b
+}
+
+func decode(b byte) input {
+	var in input
+	in.Shoot = b&1 != 0
+	in.Left = b&2 != 0
+	in.Right = b&4 != 0
+	in.Restart = b&8 != 0
+	return in
+}
+
+// Session is a running network match.
+type Session struct {
+	conn    net.PacketConn
+	peer    net.Addr
+	side    game.Side
+	opts    Options
+	rng     *rand.Rand
+	packets chan []byte
+
+	// match is the state after simulating frame-1, using predicted inputs
+	// where the remote inputs are not yet known
+	match *game.Match
+	frame int
+	// confirmed is the state after confirmedFrame-1, all inputs up to there
+	// are known
+	confirmed      *game.Match
+	confirmedFrame int
+
+	local  []input
+	remote []input
+	// newRemote is set when remote inputs arrive for frames that were already
+	// simulated with predictions
+	newRemote bool
+	// peerHas is the number of local inputs that the peer acknowledged
+	peerHas int
+	// peerAdvantage is how many frames the peer thinks it is ahead of us
+	peerAdvantage int
+	lastSync      int
+	// held collects kicks and restarts while waiting for the peer, so they
+	// are not lost
+	held input
+	// emitted are the events reported for frames that are not confirmed yet,
+	// they are compared after a rollback so events are not reported twice
+	emitted map[int][]game.Event
+}
+
+// Host waits for another player to Join at the given UDP address, e.g. ":4040",
+// and starts a match with the given seed, field width and config. Network
+// matches are always one against one.
+func Host(addr string, seed int64, width int, config game.Config, opts Options) (*Session, error) {
+	if config.Players != 2 {
+		return nil, errors.New("netplay: only two players can play over the network")
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	var buf [64]byte
+	for {
+		n, peer, err := conn.ReadFrom(buf[:])
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if n == 1 && buf[0] == helloPacket {
+			s := newSession(conn, peer, game.LeftSide, game.NewMatch(width, seed, config), opts)
+			s.sendStart()
+			return s, nil
+		}
+	}
+}
+
+// Join connects to a host at the given address, e.g. "192.168.0.2:4040". The
+// Delay in opts is replaced by the host's.
+func Join(addr string, opts Options) (*Session, error) {
+	host, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(joinTimeout)
+	var buf [2048]byte
+	for time.Now().Before(deadline) {
+		conn.WriteTo([]byte{helloPacket}, host)
+		conn.SetReadDeadline(time.Now().Add(250 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf[:])
+		if err != nil {
+			continue
+		}
+		if n >= 14 && buf[0] == startPacket {
+			conn.SetReadDeadline(time.Time{})
+			seed := int64(binary.LittleEndian.Uint64(buf[1:]))
+			width := int(int32(binary.LittleEndian.Uint32(buf[9:])))
+			opts.Delay = int(buf[13])
+			config, err := game.LoadConfig(bytes.NewReader(buf[14:n]))
+			if err != nil {
+				conn.Close()
+				return nil, errors.New("netplay: invalid config from host: " + err.Error())
+			}
+			match := game.NewMatch(width, seed, config)
+			return newSession(conn, host, game.RightSide, match, opts), nil
+		}
+	}
+	conn.Close()
+	return nil, errors.New("netplay: no answer from host " + addr)
+}
+
+func newSession(conn net.PacketConn, peer net.Addr, side game.Side, match *game.Match, opts Options) *Session {
+	if opts.Delay < 0 {
+		opts.Delay = 0
+	}
+	if opts.Delay > 255 {
+		opts.Delay = 255
+	}
+	s := &Session{
+		conn:      conn,
+		peer:      peer,
+		side:      side,
+		opts:      opts,
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		packets:   make(chan []byte, 256),
+		match:     match,
+		confirmed: match.Clone(),
+		emitted:   make(map[int][]game.Event),
+	}
+	// the first frames have no inputs because of the input delay
+	s.local = make([]input, opts.Delay)
+	go s.receive()
+	return s
+}
+
+func (s *Session) receive() {
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			close(s.packets)
+			return
+		}
+		if from.String() != s.peer.String() {
+			continue
+		}
+		select {
+		case s.packets <- append([]byte(nil), buf[:n]...):
+		default:
+			// the game is not keeping up, later packets repeat the inputs
+		}
+	}
+}
+
+func (s *Session) send(data []byte) {
+	if s.opts.Loss > 0 && s.rng.Float64() < s.opts.Loss {
+		return
+	}
+	if s.opts.Latency > 0 {
+		time.AfterFunc(s.opts.Latency, func() {
+			s.conn.WriteTo(data, s.peer)
+		})
+	} else {
+		s.conn.WriteTo(data, s.peer)
+	}
+}
+
+func (s *Session) sendStart() {
+	data := make([]byte, 14)
+	data[0] = startPacket
+	binary.LittleEndian.PutUint64(data[1:], uint64(s.match.Seed()))
+	binary.LittleEndian.PutUint32(data[9:], uint32(int32(s.match.Width)))
+	data[13] = byte(s.opts.Delay)
+	config, _ := json.Marshal(s.match.Config)
+	s.send(append(data, config...))
+}
+
+// sendInputs sends all local inputs that the peer does not have yet and tells
+// it how many of its inputs have arrived here.
+func (s *Session) sendInputs() {
+	first := s.peerHas
+	if len(s.local)-first > maxResend {
+		first = len(s.local) - maxResend
+	}
+	data := make([]byte, 10, 10+len(s.local)-first)
+	data[0] = inputsPacket
+	binary.LittleEndian.PutUint32(data[1:], uint32(len(s.remote)))
+	binary.LittleEndian.PutUint32(data[5:], uint32(first))
+	data[9] = byte(int8(clamp(s.advantage(), -128, 127)))
+	for _, in := range s.local[first:] {
+		data = append(data, in.encode())
+	}
+	s.send(data)
+}
+
+func (s *Session) handle(data []byte) {
+	switch {
+	case len(data) == 1 && data[0] == helloPacket && s.side == game.LeftSide:
+		// our start packet got lost
+		s.sendStart()
+	case len(data) >= 10 && data[0] == inputsPacket:
+		if has := int(binary.LittleEndian.Uint32(data[1:])); has > s.peerHas {
+			s.peerHas = has
+		}
+		first := int(binary.LittleEndian.Uint32(data[5:]))
+		s.peerAdvantage = int(int8(data[9]))
+		for i, b := range data[10:] {
+			frame := first + i
+			if frame == len(s.remote) {
+				s.remote = append(s.remote, decode(b))
+				if frame < s.frame {
+					s.newRemote = true
+				}
+			}
+		}
+	}
+}
+
+// Side returns the kiwi that is controlled on this computer.
+func (s *Session) Side() game.Side {
+	return s.side
+}
+
+// Match returns the current state of the match for drawing it. The returned
+// Match changes after a rollback, call Match again after every Advance.
+func (s *Session) Match() *game.Match {
+	return s.match
+}
+
+// Advance is called once per frame with the local player's inputs, restart is
+// true if the player wants to restart a finished match. It exchanges inputs
+// with the other side and advances the match by one frame. If the other side
+// is too far behind, the match waits for it and the inputs are ignored.
+// The events of frames that were simulated again after a rollback are reported
+// late, if they did not happen with the predicted inputs.
+func (s *Session) Advance(local game.PlayerInput, restart bool) []game.Event {
+	for done := false; !done; {
+		select {
+		case data, ok := <-s.packets:
+			if !ok {
+				done = true
+			} else {
+				s.handle(data)
+			}
+		default:
+			done = true
+		}
+	}
+
+	in := input{PlayerInput: local, Restart: restart}
+	in.Shoot = in.Shoot || s.held.Shoot
+	in.Restart = in.Restart || s.held.Restart
+	if s.waiting() {
+		s.held = in
+		s.sendInputs()
+		return nil
+	}
+	s.held = input{}
+	s.local = append(s.local, in)
+	s.sendInputs()
+
+	var events []game.Event
+	if s.newRemote {
+		s.newRemote = false
+		events = s.rollback()
+	}
+
+	events = append(events, s.step(s.match, s.frame)...)
+	s.frame++
+	s.confirm()
+	return events
+}
+
+// waiting reports whether this frame is skipped, either because the remote
+// inputs are missing for too long or because we are running ahead of the
+// other side and it needs to catch up.
+func (s *Session) waiting() bool {
+	if s.frame-len(s.remote) >= maxPrediction {
+		return true
+	}
+	// both sides see each other with the same latency, if we are further
+	// ahead than the peer thinks it is, we are really ahead
+	if (s.advantage()-s.peerAdvantage)/2 >= 1 && s.frame-s.lastSync >= syncInterval {
+		s.lastSync = s.frame
+		return true
+	}
+	return false
+}
+
+// advantage is the number of frames that we are ahead of the latest frame we
+// got from the peer.
+func (s *Session) advantage() int {
+	peerFrame := len(s.remote) - 1 - s.opts.Delay
+	return s.frame - peerFrame
+}
+
+// rollback simulates all frames since the last confirmed one again, now that
+// more remote inputs are known. It returns the events that were not reported
+// before.
+func (s *Session) rollback() []game.Event {
+	var events []game.Event
+	m := s.confirmed.Clone()
+	for f := s.confirmedFrame; f < s.frame; f++ {
+		events = append(events, s.step(m, f)...)
+	}
+	s.match = m
+	s.confirm()
+	return events
+}
+
+// step advances m by the given frame and returns the events that were not
+// reported for this frame before.
+func (s *Session) step(m *game.Match, frame int) []game.Event {
+	var events []game.Event
+	for _, e := range m.Step(s.inputs(frame)) {
+		if !contains(s.emitted[frame], e) {
+			events = append(events, e)
+			s.emitted[frame] = append(s.emitted[frame], e)
+		}
+	}
+	return events
+}
+
+// confirm moves the confirmed state forward as far as all inputs are known.
+func (s *Session) confirm() {
+	known := len(s.remote)
+	if known > s.frame {
+		known = s.frame
+	}
+	for s.confirmedFrame < known {
+		s.confirmed.Step(s.inputs(s.confirmedFrame))
+		delete(s.emitted, s.confirmedFrame)
+		s.confirmedFrame++
+	}
+}
+
+// inputs returns the inputs for both kiwis in the given frame. Unknown remote
+// inputs are predicted to be the same as the last known ones, buttons that
+// are held down usually stay down for a while, but without restarting.
+func (s *Session) inputs(frame int) game.Inputs {
+	local := s.local[frame]
+	var remote input
+	if frame < len(s.remote) {
+		remote = s.remote[frame]
+	} else if len(s.remote) > 0 {
+		remote = s.remote[len(s.remote)-1]
+		remote.Restart = false
+	}
+	// player 0 is the blue kiwi and player 1 the white one, see game.Team
+	in := game.Inputs{Restart: local.Restart || remote.Restart}
+	if s.side == game.LeftSide {
+		in.Players[0], in.Players[1] = local.PlayerInput, remote.PlayerInput
+	} else {
+		in.Players[0], in.Players[1] = remote.PlayerInput, local.PlayerInput
+	}
+	return in
+}
+
+// ConfirmedInputs returns the inputs of all frames that both sides agree on,
+// e.g. for recording the match.
+func (s *Session) ConfirmedInputs() []game.Inputs {
+	inputs := make([]game.Inputs, s.confirmedFrame)
+	for f := range inputs {
+		inputs[f] = s.inputs(f)
+	}
+	return inputs
+}
+
+// Close ends the session.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
+
+// contains compares the events only by their type and side, the same event
+// can happen at another place or speed after a rollback.
+func contains(events []game.Event, e game.Event) bool {
+	for _, x := range events {
+		if x.Type == e.Type && x.Side == e.Side {
+			return true
+		}
+	}
+	return false
+}
+
+func clamp(x, min, max int) int {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
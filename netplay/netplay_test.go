@@ -0,0 +1,149 @@
+package netplay
+
+import (
+	"math/rand"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gonutz/jolina/game"
+)
+
+// testFrames is the number of frames that both sides have to confirm.
+const testFrames = 300
+
+func TestSidesAgree(t *testing.T) {
+	tests := []struct {
+		name    string
+		loss    float64
+		latency time.Duration
+	}{
+		{"perfect network", 0, 0},
+		{"loss", 0.2, 0},
+		{"latency", 0, 40 * time.Millisecond},
+		{"loss and latency", 0.2, 40 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Delay: 2, Loss: tt.loss, Latency: tt.latency}
+			host, join := play(t, opts)
+			defer host.Close()
+			defer join.Close()
+
+			hostInputs := host.ConfirmedInputs()
+			joinInputs := join.ConfirmedInputs()
+			if len(hostInputs) < testFrames || len(joinInputs) < testFrames {
+				t.Fatalf("only %d and %d frames were confirmed",
+					len(hostInputs), len(joinInputs))
+			}
+			for f := 0; f < testFrames; f++ {
+				if hostInputs[f] != joinInputs[f] {
+					t.Fatalf("frame %d: the host confirmed %v but the joining side %v",
+						f, hostInputs[f], joinInputs[f])
+				}
+			}
+
+			// the confirmed states are the matches played with the confirmed
+			// inputs
+			for _, s := range []*Session{host, join} {
+				if m := replay(s, s.ConfirmedInputs()); !reflect.DeepEqual(m, s.confirmed) {
+					t.Errorf("the confirmed match of side %d differs from its inputs", s.side)
+				}
+			}
+			a := replay(host, hostInputs[:testFrames])
+			b := replay(join, joinInputs[:testFrames])
+			if !reflect.DeepEqual(a, b) {
+				t.Errorf("the matches differ after %d frames:\nhost %+v\njoin %+v",
+					testFrames, a, b)
+			}
+		})
+	}
+}
+
+// play hosts a match on a free port on the local machine, joins it and
+// advances both sides with random inputs until both confirmed testFrames
+// frames, or gives up after a while. The host starts advancing right away, it
+// has to answer the joining side again if its start packet got lost.
+func play(t *testing.T, opts Options) (host, join *Session) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	config := game.DefaultConfig()
+	// kicks without charging get a random speed
+	config.ChargeFrames = 0
+
+	var mu sync.Mutex
+	confirmed := 0
+	failed := false
+	allDone := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return confirmed == 2 || failed
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	advance := func(s *Session, seed int64) {
+		r := rand.New(rand.NewSource(seed))
+		var in game.PlayerInput
+		done := false
+		for !allDone() && time.Now().Before(deadline) {
+			if r.Intn(10) == 0 {
+				in.Left = r.Intn(2) == 0
+				in.Right = !in.Left && r.Intn(2) == 0
+			}
+			in.Shoot = r.Intn(3) == 0
+			s.Advance(in, r.Intn(200) == 0)
+			if !done && len(s.ConfirmedInputs()) >= testFrames {
+				done = true
+				mu.Lock()
+				confirmed++
+				mu.Unlock()
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var hostErr, joinErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		host, hostErr = Host(addr, 5, 1600, config, opts)
+		if hostErr == nil {
+			advance(host, 1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		join, joinErr = Join(addr, opts)
+		if joinErr == nil {
+			advance(join, 2)
+		} else {
+			mu.Lock()
+			failed = true
+			mu.Unlock()
+		}
+	}()
+	wg.Wait()
+	if hostErr != nil || joinErr != nil {
+		if host != nil {
+			host.Close()
+		}
+		t.Fatal(hostErr, joinErr)
+	}
+	return host, join
+}
+
+// replay plays the session's match from the start with the given inputs.
+func replay(s *Session, inputs []game.Inputs) *game.Match {
+	m := game.NewMatch(s.match.Width, s.match.Seed(), s.match.Config)
+	for _, in := range inputs {
+		m.Step(in)
+	}
+	return m
+}
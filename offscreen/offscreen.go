@@ -0,0 +1,488 @@
+// Package offscreen implements a draw.Window that draws into an image in
+// memory instead of a real window. It needs no display and no graphics card,
+// so the game's frame function can run anywhere, e.g. to compare what it
+// draws with golden images or to save screenshots.
+//
+// The drawing is done in software and follows the OpenGL implementation of
+// the draw package: the window is cleared to black before each frame, images
+// and text are scaled with nearest neighbor sampling and rotated about their
+// centers, colors are alpha blended. The results are not pixel exact copies
+// of what a graphics card draws but they are the same on every computer.
+//
+// Sounds are not played, their paths are recorded instead. The keys are not
+// read from a keyboard, they are set with PressKey and SetKeyDown.
+//
+// The types of the draw package are used in the method signatures so a Window
+// is a draw.Window. The draw package needs the OpenGL and X11 development
+// packages on Linux, even if no window is opened. Build with the tag headless
+// to use the copies of its types in the game's draw package instead, e.g. on
+// a build server without them:
+//
+//	go test -tags headless ./...
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	_ "image/png"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Window is a draw.Window that draws into an image. Run a frame with Frame.
+type Window struct {
+	// Open reads the image and sound files, it defaults to draw.OpenFile.
+	Open func(path string) (io.ReadCloser, error)
+	// Sounds are the paths of all sounds that were played, in order.
+	Sounds []string
+
+	img        *image.RGBA
+	images     map[string]*image.NRGBA
+	down       map[Key]bool
+	pressed    map[Key]bool
+	closed     bool
+	fullscreen bool
+}
+
+// New creates a window of the given size in pixels.
+func New(width, height int) *Window {
+	return &Window{
+		Open:    openFile,
+		img:     image.NewRGBA(image.Rect(0, 0, width, height)),
+		images:  make(map[string]*image.NRGBA),
+		down:    make(map[Key]bool),
+		pressed: make(map[Key]bool),
+	}
+}
+
+// Frame clears the window to black and calls the update function once, like
+// draw.RunWindow does for every frame. Afterwards the keys that were pressed
+// with PressKey are released.
+func (w *Window) Frame(update UpdateFunction) {
+	// the first black pixel is copied to the rest of the image, doubling the
+	// black part each time
+	pix := w.img.Pix
+	if len(pix) > 0 {
+		copy(pix, []uint8{0, 0, 0, 255})
+		for n := 4; n < len(pix); n *= 2 {
+			copy(pix[n:], pix[:n])
+		}
+	}
+	update(w)
+	w.pressed = make(map[Key]bool)
+}
+
+// Image returns what was drawn so far. The image is drawn over in the next
+// frame, copy it to keep it.
+func (w *Window) Image() *image.RGBA {
+	return w.img
+}
+
+// PressKey makes the key count as pressed during the next frame. Unless it
+// is also held down with SetKeyDown it is released at the end of the frame.
+func (w *Window) PressKey(key Key) {
+	w.pressed[key] = true
+}
+
+// SetKeyDown holds the key down or releases it. Holding down a key that was
+// up also counts as pressing it.
+func (w *Window) SetKeyDown(key Key, down bool) {
+	if down && !w.down[key] {
+		w.pressed[key] = true
+	}
+	w.down[key] = down
+}
+
+// Closed reports whether Close was called.
+func (w *Window) Closed() bool {
+	return w.closed
+}
+
+// Fullscreen reports whether the window was last set to fullscreen. This does
+// not change the size of the window.
+func (w *Window) Fullscreen() bool {
+	return w.fullscreen
+}
+
+func (w *Window) Close() {
+	w.closed = true
+}
+
+func (w *Window) Size() (int, int) {
+	b := w.img.Bounds()
+	return b.Dx(), b.Dy()
+}
+
+func (w *Window) SetFullscreen(f bool) {
+	w.fullscreen = f
+}
+
+func (w *Window) ShowCursor(show bool) {}
+
+func (w *Window) WasKeyPressed(key Key) bool {
+	return w.pressed[key]
+}
+
+func (w *Window) IsKeyDown(key Key) bool {
+	return w.down[key] || w.pressed[key]
+}
+
+func (w *Window) Characters() string {
+	return ""
+}
+
+func (w *Window) IsMouseDown(button MouseButton) bool {
+	return false
+}
+
+func (w *Window) Clicks() []MouseClick {
+	return nil
+}
+
+func (w *Window) MousePosition() (int, int) {
+	return 0, 0
+}
+
+func (w *Window) MouseWheelY() float64 {
+	return 0
+}
+
+func (w *Window) MouseWheelX() float64 {
+	return 0
+}
+
+// blend draws the color over the pixel at x,y.
+func (w *Window) blend(x, y int, c Color) {
+	if !(image.Point{x, y}.In(w.img.Bounds())) {
+		return
+	}
+	a := clamp(c.A)
+	i := w.img.PixOffset(x, y)
+	p := w.img.Pix[i : i+4 : i+4]
+	for j, v := range [4]float32{clamp(c.R) * a, clamp(c.G) * a, clamp(c.B) * a, a} {
+		p[j] = uint8(v*255 + float32(p[j])*(1-a) + 0.5)
+	}
+}
+
+func clamp(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func (w *Window) DrawPoint(x, y int, color Color) {
+	w.blend(x, y, color)
+}
+
+func (w *Window) DrawLine(fromX, fromY, toX, toY int, color Color) {
+	// Bresenham's algorithm, both end points are drawn
+	dx, dy := abs(toX-fromX), -abs(toY-fromY)
+	sx, sy := sign(toX-fromX), sign(toY-fromY)
+	e := dx + dy
+	x, y := fromX, fromY
+	for {
+		w.blend(x, y, color)
+		if x == toX && y == toY {
+			return
+		}
+		if 2*e >= dy {
+			e += dy
+			x += sx
+		}
+		if 2*e <= dx {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int {
+	if x < 0 {
+		return -1
+	}
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (w *Window) DrawRect(x, y, width, height int, color Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	for i := x; i < x+width; i++ {
+		w.blend(i, y, color)
+		if height > 1 {
+			w.blend(i, y+height-1, color)
+		}
+	}
+	for j := y + 1; j < y+height-1; j++ {
+		w.blend(x, j, color)
+		if width > 1 {
+			w.blend(x+width-1, j, color)
+		}
+	}
+}
+
+func (w *Window) FillRect(x, y, width, height int, color Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	r := image.Rect(x, y, x+width, y+height).Intersect(w.img.Bounds())
+	for j := r.Min.Y; j < r.Max.Y; j++ {
+		for i := r.Min.X; i < r.Max.X; i++ {
+			w.blend(i, j, color)
+		}
+	}
+}
+
+// inEllipse reports whether the center of pixel i,j lies in the ellipse in the
+// rectangle x,y,width,height.
+func inEllipse(i, j, x, y, width, height int) bool {
+	rx, ry := float64(width)/2, float64(height)/2
+	dx := (float64(i-x) + 0.5 - rx) / rx
+	dy := (float64(j-y) + 0.5 - ry) / ry
+	return dx*dx+dy*dy <= 1
+}
+
+func (w *Window) DrawEllipse(x, y, width, height int, color Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	// the outline are the pixels in the ellipse next to a pixel outside
+	for j := y; j < y+height; j++ {
+		for i := x; i < x+width; i++ {
+			if inEllipse(i, j, x, y, width, height) &&
+				!(inEllipse(i-1, j, x, y, width, height) &&
+					inEllipse(i+1, j, x, y, width, height) &&
+					inEllipse(i, j-1, x, y, width, height) &&
+					inEllipse(i, j+1, x, y, width, height)) {
+				w.blend(i, j, color)
+			}
+		}
+	}
+}
+
+func (w *Window) FillEllipse(x, y, width, height int, color Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	for j := y; j < y+height; j++ {
+		for i := x; i < x+width; i++ {
+			if inEllipse(i, j, x, y, width, height) {
+				w.blend(i, j, color)
+			}
+		}
+	}
+}
+
+// loadImage reads the image file the first time it is drawn.
+func (w *Window) loadImage(path string) (*image.NRGBA, error) {
+	if img, ok := w.images[path]; ok {
+		return img, nil
+	}
+	f, err := w.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	w.images[path] = toNRGBA(img)
+	return w.images[path], nil
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			out.SetNRGBA(x-b.Min.X, y-b.Min.Y, c)
+		}
+	}
+	return out
+}
+
+func (w *Window) DrawImageFile(path string, x, y int) error {
+	return w.DrawImageFileRotated(path, x, y, 0)
+}
+
+func (w *Window) DrawImageFileTo(path string, x, y, width, height, rotationCWDeg int) error {
+	img, err := w.loadImage(path)
+	if err != nil {
+		return err
+	}
+	if width == -1 && height == -1 {
+		width, height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+	w.drawImage(img, 0, 0, img.Bounds().Dx(), img.Bounds().Dy(), x, y, width, height, rotationCWDeg, white)
+	return nil
+}
+
+func (w *Window) DrawImageFileRotated(path string, x, y, rotationCWDeg int) error {
+	return w.DrawImageFileTo(path, x, y, -1, -1, rotationCWDeg)
+}
+
+func (w *Window) DrawImageFilePart(
+	path string,
+	sourceX, sourceY, sourceWidth, sourceHeight int,
+	destX, destY, destWidth, destHeight int,
+	rotationCWDeg int,
+) error {
+	img, err := w.loadImage(path)
+	if err != nil {
+		return err
+	}
+	w.drawImage(
+		img,
+		sourceX, sourceY, sourceWidth, sourceHeight,
+		destX, destY, destWidth, destHeight,
+		rotationCWDeg, white,
+	)
+	return nil
+}
+
+// drawImage draws the source rectangle of the image to the destination
+// rectangle, rotated clockwise about its center. A negative source width or
+// height flips the image. The image colors are multiplied by the tint.
+func (w *Window) drawImage(
+	img *image.NRGBA,
+	sx, sy, sw, sh int,
+	dx, dy, dw, dh int,
+	degrees int, tint Color,
+) {
+	if dw <= 0 || dh <= 0 || sw == 0 || sh == 0 {
+		return
+	}
+	cx, cy := float64(dx)+float64(dw)/2, float64(dy)+float64(dh)/2
+	sin, cos := math.Sincos(float64(degrees) / 180 * math.Pi)
+
+	// only the pixels in the bounds of the rotated rectangle are looked at
+	left, top, right, bottom := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		x, y := p[0]*float64(dw)/2, p[1]*float64(dh)/2
+		x, y = cos*x-sin*y+cx, sin*x+cos*y+cy
+		left, right = math.Min(left, x), math.Max(right, x)
+		top, bottom = math.Min(top, y), math.Max(bottom, y)
+	}
+	r := image.Rect(
+		int(math.Floor(left)), int(math.Floor(top)),
+		int(math.Ceil(right)), int(math.Ceil(bottom)),
+	).Intersect(w.img.Bounds())
+
+	for j := r.Min.Y; j < r.Max.Y; j++ {
+		for i := r.Min.X; i < r.Max.X; i++ {
+			// rotate the pixel center back into the unrotated rectangle
+			x, y := float64(i)+0.5-cx, float64(j)+0.5-cy
+			x, y = cos*x+sin*y, -sin*x+cos*y
+			u, v := x/float64(dw)+0.5, y/float64(dh)+0.5
+			if u < 0 || u >= 1 || v < 0 || v >= 1 {
+				continue
+			}
+			c, ok := sample(img, sx, sw, u, sy, sh, v)
+			if !ok {
+				continue
+			}
+			w.blend(i, j, Color{
+				R: tint.R * float32(c.R) / 255,
+				G: tint.G * float32(c.G) / 255,
+				B: tint.B * float32(c.B) / 255,
+				A: tint.A * float32(c.A) / 255,
+			})
+		}
+	}
+}
+
+// sample returns the image pixel at the relative position u,v in the source
+// rectangle.
+func sample(img *image.NRGBA, sx, sw int, u float64, sy, sh int, v float64) (color.NRGBA, bool) {
+	x, y := pixel(sx, sw, u), pixel(sy, sh, v)
+	if !(image.Point{x, y}.In(img.Bounds())) {
+		return color.NRGBA{}, false
+	}
+	return img.NRGBAAt(x, y), true
+}
+
+// pixel returns the pixel at the fraction f of the source range that starts at
+// start and goes size pixels to the right, or to the left if size is negative.
+func pixel(start, size int, f float64) int {
+	p := float64(start) + f*float64(size)
+	if size < 0 {
+		return int(math.Ceil(p)) - 1
+	}
+	return int(math.Floor(p))
+}
+
+func (w *Window) GetTextSize(text string) (int, int) {
+	return w.GetScaledTextSize(text, 1)
+}
+
+func (w *Window) GetScaledTextSize(text string, scale float32) (int, int) {
+	charW := int(fontCharW*scale + 0.5)
+	charH := int(fontCharH*scale + 0.5)
+	lines := strings.Split(text, "\n")
+	maxLineW := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > maxLineW {
+			maxLineW = n
+		}
+	}
+	return charW * maxLineW, charH * len(lines)
+}
+
+func (w *Window) DrawText(text string, x, y int, color Color) {
+	w.DrawScaledText(text, x, y, 1, color)
+}
+
+func (w *Window) DrawScaledText(text string, x, y int, scale float32, color Color) {
+	if scale <= 0 {
+		return
+	}
+	glyphs := font()
+	charW := int(fontCharW*scale + 0.5)
+	charH := int(fontCharH*scale + 0.5)
+	destX, destY := x, y
+	for _, r := range text {
+		if r == '\n' {
+			destX = x
+			destY += charH
+			continue
+		}
+		r = runeToFont(r)
+		w.drawImage(
+			glyphs,
+			int(r%16)*fontCharW, int(r/16)*fontCharH, fontCharW, fontCharH,
+			destX, destY, charW, charH,
+			0, color,
+		)
+		destX += charW
+	}
+}
+
+// PlaySoundFile records the path in Sounds. Like a real window it returns an
+// error if the file cannot be opened.
+func (w *Window) PlaySoundFile(path string) error {
+	f, err := w.Open(path)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	w.Sounds = append(w.Sounds, path)
+	return nil
+}
@@ -0,0 +1,37 @@
+package offscreen
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/gonutz/jolina/offscreen/golden"
+)
+
+func rgba(r, g, b, a float32) Color {
+	return Color{R: r, G: g, B: b, A: a}
+}
+
+func TestDrawing(t *testing.T) {
+	w := New(200, 150)
+	w.Open = func(path string) (io.ReadCloser, error) { return os.Open(path) }
+	// Frame would clear the window to black, PNG files cannot store
+	// transparent pixels of an image.RGBA exactly
+	w.FillRect(0, 0, 200, 150, rgba(0, 0, 0, 1))
+	w.FillRect(10, 10, 60, 40, rgba(0.5, 1, 0.5, 1))
+	w.DrawRect(5, 5, 70, 50, rgba(1, 1, 1, 1))
+	w.DrawLine(0, 149, 199, 60, rgba(1, 0, 0, 1))
+	w.FillEllipse(90, 10, 50, 30, rgba(0, 0, 1, 1))
+	w.DrawEllipse(140, 10, 50, 50, rgba(1, 1, 0, 1))
+	// colors are blended over what was drawn before
+	w.FillRect(100, 20, 80, 30, rgba(1, 1, 1, 0.5))
+	if err := w.DrawImageFileRotated("../rsc/ball.png", 10, 70, 30); err != nil {
+		t.Fatal(err)
+	}
+	// a negative source width mirrors the image
+	if err := w.DrawImageFilePart("../rsc/ball.png", 60, 0, -60, 60, 80, 70, 30, 60, 0); err != nil {
+		t.Fatal(err)
+	}
+	w.DrawScaledText("Kiwi\n1 : 0", 120, 70, 1.5, rgba(1, 1, 1, 1))
+	golden.Check(t, "drawing", w.Image())
+}
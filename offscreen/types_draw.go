@@ -0,0 +1,26 @@
+//go:build !headless
+
+package offscreen
+
+import (
+	"io"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// The types of the draw package make a Window a draw.Window.
+type (
+	Color          = draw.Color
+	Key            = draw.Key
+	MouseButton    = draw.MouseButton
+	MouseClick     = draw.MouseClick
+	UpdateFunction = draw.UpdateFunction
+)
+
+var white = draw.White
+
+// openFile reads the file with draw.OpenFile, which the game can redirect to
+// its embedded files after the Window was created.
+func openFile(path string) (io.ReadCloser, error) {
+	return draw.OpenFile(path)
+}
@@ -0,0 +1,26 @@
+//go:build headless
+
+package offscreen
+
+import (
+	"io"
+
+	"github.com/gonutz/jolina/draw"
+)
+
+// The types of the game's draw package, which are copies of the
+// prototype/draw package's types with the headless tag.
+type (
+	Color          = draw.Color
+	Key            = draw.Key
+	MouseButton    = draw.MouseButton
+	MouseClick     = draw.MouseClick
+	UpdateFunction = draw.UpdateFunction
+)
+
+var white = draw.White
+
+// openFile reads the file with the game's draw.OpenFile.
+func openFile(path string) (io.ReadCloser, error) {
+	return draw.OpenFile(path)
+}
@@ -0,0 +1,43 @@
+// Package pad reads the state of game controllers. Which system library is
+// used depends on the same build tags as the prototype/draw package: on
+// Windows DirectInput is used by default, with the glfw or sdl2 tags (and on
+// other platforms) the joysticks of that library are used. The button numbers
+// depend on the library, StartButton is the bit of the Start button in each.
+// With the headless tag no library is used and there are no pads.
+package pad
+
+// State is a snapshot of a game pad. X and Y are the position of the main
+// axes, X from -1 (left) to 1 (right), Y from -1 (up) to 1 (down). Buttons
+// has bit i set while button i is held down, Pressed has bit i set if button
+// i was pressed since the last Poll, even if it was released again in
+// between.
+type State struct {
+	X, Y    float64
+	Buttons uint32
+	Pressed uint32
+}
+
+// Pads are the game pads connected to the computer.
+type Pads interface {
+	// Poll returns the current state of every pad.
+	Poll() []State
+	// Close releases the pads.
+	Close()
+}
+
+// Fake is a Pads implementation for tests, Poll returns the States, Close
+// does nothing.
+type Fake struct {
+	States []State
+}
+
+func (f *Fake) Poll() []State {
+	return f.States
+}
+
+func (f *Fake) Close() {}
+
+// pressedSince returns the bits that are set in now but were not in before.
+func pressedSince(before, now uint32) uint32 {
+	return now &^ before
+}
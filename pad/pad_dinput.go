@@ -0,0 +1,127 @@
+//go:build windows && !glfw && !sdl2 && !headless
+
+package pad
+
+import (
+	"errors"
+
+	"github.com/gonutz/di8"
+	"github.com/gonutz/w32"
+)
+
+// StartButton is the Start button of Xbox controllers, which most other pads
+// copy.
+const StartButton = 1 << 7
+
+type dinputPads struct {
+	dinput  *di8.DirectInput
+	devices []*di8.Device
+	buf     [32]di8.DEVICEOBJECTDATA
+}
+
+// Open opens up to max connected game pads. It has to be called after the game
+// window was created and is active.
+func Open(max int) (Pads, error) {
+	dinput, err := di8.Create(di8.HINSTANCE(uintptr(w32.GetModuleHandle(""))))
+	if err != nil {
+		return nil, errors.New("pad: unable to create DirectInput: " + err.Error())
+	}
+	p := &dinputPads{dinput: dinput}
+	var insts []di8.DEVICEINSTANCE
+	dinput.EnumDevices(
+		di8.DEVCLASS_GAMECTRL,
+		func(inst *di8.DEVICEINSTANCE, ref uintptr) uintptr {
+			insts = append(insts, *inst)
+			return 1
+		},
+		0,
+		di8.EDFL_ATTACHEDONLY,
+	)
+	window := di8.HWND(w32.GetActiveWindow())
+	for i := range insts {
+		if len(p.devices) == max {
+			break
+		}
+		dev, err := dinput.CreateDevice(insts[i].GuidInstance)
+		if err == nil {
+			err = dev.SetCooperativeLevel(
+				window,
+				di8.SCL_EXCLUSIVE|di8.SCL_FOREGROUND,
+			)
+			if err != nil {
+				continue
+			}
+			err = dev.SetDataFormat(&di8.Joystick)
+			if err != nil {
+				continue
+			}
+			err = dev.SetProperty(
+				di8.PROP_BUFFERSIZE,
+				di8.NewPropDWord(0, di8.PH_DEVICE, 32),
+			)
+			if err != nil {
+				continue
+			}
+			err = dev.Acquire()
+			if err != nil {
+				continue
+			}
+			p.devices = append(p.devices, dev)
+		}
+	}
+	return p, nil
+}
+
+func (p *dinputPads) Poll() []State {
+	states := make([]State, len(p.devices))
+	for i, dev := range p.devices {
+		states[i] = p.poll(dev)
+	}
+	return states
+}
+
+func (p *dinputPads) poll(dev *di8.Device) State {
+	var s State
+	var state di8.JOYSTATE
+	if err := dev.GetDeviceState(&state); err == nil {
+		s.X = axisPos(uint32(state.X))
+		s.Y = axisPos(uint32(state.Y))
+		for i, b := range state.Buttons {
+			if b&0x80 != 0 {
+				s.Buttons |= 1 << uint(i)
+			}
+		}
+		// the buffered data contains all button presses, even those that were
+		// released again since the last poll
+		n, err := dev.GetDeviceData(p.buf[:], 0)
+		if err == nil {
+			for _, data := range p.buf[:n] {
+				if di8.JOFS_BUTTON0 <= data.Ofs && data.Ofs <= di8.JOFS_BUTTON31 &&
+					data.Data&0xFF != 0 {
+					s.Pressed |= 1 << (data.Ofs - di8.JOFS_BUTTON0)
+				}
+			}
+		} else if err.Code() == di8.ERR_INPUTLOST || err.Code() == di8.ERR_NOTACQUIRED {
+			dev.Acquire()
+		}
+	} else if err.Code() == di8.ERR_INPUTLOST || err.Code() == di8.ERR_NOTACQUIRED {
+		dev.Acquire()
+	}
+	return s
+}
+
+func (p *dinputPads) Close() {
+	for i := range p.devices {
+		p.devices[i].Release()
+	}
+	p.dinput.Release()
+}
+
+func axisPos(data uint32) float64 {
+	n := int(data) - 0xFFFF/2
+	if n < 0 {
+		return float64(n) / 32767
+	} else {
+		return float64(n) / 32768
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !headless && (glfw || (!windows && !sdl2))
+
+package pad
+
+import "github.com/gonutz/glfw/v3.3/glfw"
+
+// StartButton is the Start button of Xbox controllers, which most other pads
+// copy.
+const StartButton = 1 << 7
+
+type glfwPads struct {
+	max  int
+	prev map[glfw.Joystick]uint32
+}
+
+// Open opens up to max connected joysticks. It has to be called after the game
+// window was created, glfw has to be initialized.
+func Open(max int) (Pads, error) {
+	return &glfwPads{max: max, prev: make(map[glfw.Joystick]uint32)}, nil
+}
+
+func (p *glfwPads) Poll() []State {
+	var states []State
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast && len(states) < p.max; joy++ {
+		if !joy.Present() {
+			continue
+		}
+		var s State
+		axes := joy.GetAxes()
+		if len(axes) > 0 {
+			s.X = float64(axes[0])
+		}
+		if len(axes) > 1 {
+			s.Y = float64(axes[1])
+		}
+		// GLFW reports the digital pads as 4 extra buttons each after the
+		// real buttons, they move the main axes instead
+		hats := joy.GetHats()
+		buttons := joy.GetButtons()
+		if n := len(buttons) - 4*len(hats); n >= 0 {
+			buttons = buttons[:n]
+		}
+		for i, b := range buttons {
+			if i < 32 && b == glfw.Press {
+				s.Buttons |= 1 << uint(i)
+			}
+		}
+		if len(hats) > 0 {
+			if hats[0]&glfw.HatLeft != 0 {
+				s.X = -1
+			}
+			if hats[0]&glfw.HatRight != 0 {
+				s.X = 1
+			}
+			if hats[0]&glfw.HatUp != 0 {
+				s.Y = -1
+			}
+			if hats[0]&glfw.HatDown != 0 {
+				s.Y = 1
+			}
+		}
+		s.Pressed = pressedSince(p.prev[joy], s.Buttons)
+		p.prev[joy] = s.Buttons
+		states = append(states, s)
+	}
+	return states
+}
+
+func (p *glfwPads) Close() {}
@@ -0,0 +1,74 @@
+//go:build sdl2 && !glfw && !headless
+
+package pad
+
+import "github.com/gonutz/go-sdl2/sdl"
+
+// StartButton is the Start button of SDL's game controller mapping.
+const StartButton = 1 << sdl.CONTROLLER_BUTTON_START
+
+// dpadButtons are the bits of the digital pad, they are left out of
+// State.Buttons.
+const dpadButtons = 1<<sdl.CONTROLLER_BUTTON_DPAD_UP |
+	1<<sdl.CONTROLLER_BUTTON_DPAD_DOWN |
+	1<<sdl.CONTROLLER_BUTTON_DPAD_LEFT |
+	1<<sdl.CONTROLLER_BUTTON_DPAD_RIGHT
+
+type sdlPads struct {
+	controllers []*sdl.GameController
+	prev        []uint32
+}
+
+// Open opens up to max connected game controllers. It has to be called after
+// the game window was created, SDL has to be initialized.
+func Open(max int) (Pads, error) {
+	p := &sdlPads{}
+	for i := 0; i < sdl.NumJoysticks() && len(p.controllers) < max; i++ {
+		if !sdl.IsGameController(i) {
+			continue
+		}
+		if c := sdl.GameControllerOpen(i); c != nil {
+			p.controllers = append(p.controllers, c)
+		}
+	}
+	p.prev = make([]uint32, len(p.controllers))
+	return p, nil
+}
+
+func (p *sdlPads) Poll() []State {
+	sdl.GameControllerUpdate()
+	states := make([]State, len(p.controllers))
+	for i, c := range p.controllers {
+		s := &states[i]
+		s.X = float64(c.Axis(sdl.CONTROLLER_AXIS_LEFTX)) / 32768
+		s.Y = float64(c.Axis(sdl.CONTROLLER_AXIS_LEFTY)) / 32768
+		for b := sdl.GameControllerButton(0); b < sdl.CONTROLLER_BUTTON_MAX; b++ {
+			if c.Button(b) != 0 {
+				s.Buttons |= 1 << uint(b)
+			}
+		}
+		// the digital pad moves the main axes, it does not count as buttons
+		s.Buttons &^= dpadButtons
+		if c.Button(sdl.CONTROLLER_BUTTON_DPAD_LEFT) != 0 {
+			s.X = -1
+		}
+		if c.Button(sdl.CONTROLLER_BUTTON_DPAD_RIGHT) != 0 {
+			s.X = 1
+		}
+		if c.Button(sdl.CONTROLLER_BUTTON_DPAD_UP) != 0 {
+			s.Y = -1
+		}
+		if c.Button(sdl.CONTROLLER_BUTTON_DPAD_DOWN) != 0 {
+			s.Y = 1
+		}
+		s.Pressed = pressedSince(p.prev[i], s.Buttons)
+		p.prev[i] = s.Buttons
+	}
+	return states
+}
+
+func (p *sdlPads) Close() {
+	for _, c := range p.controllers {
+		c.Close()
+	}
+}
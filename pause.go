@@ -0,0 +1,111 @@
+package main
+
+import "github.com/gonutz/jolina/draw"
+
+// pauseScene is shown over a match that was paused with Escape or the Start
+// button on a pad. The match stands still until it is resumed, except for a
+// network match which cannot wait for the other player.
+type pauseScene struct {
+	match *matchScene
+	menu  menu
+	// confirmQuit asks whether to really leave the match, so pressing the
+	// wrong key does not end it
+	confirmQuit bool
+	quitMenu    menu
+}
+
+func newPauseScene(m *matchScene) *pauseScene {
+	m.paused = true
+	return &pauseScene{match: m}
+}
+
+// resume continues the match, if restart is true it starts over.
+func (s *pauseScene) resume(restart bool) scene {
+	s.match.paused = false
+	s.match.restartPending = restart
+	return s.match
+}
+
+func (s *pauseScene) items() []menuItem {
+	m := s.match
+	if s.confirmQuit {
+		return []menuItem{
+			{text: tr("no"), activate: func() scene {
+				s.confirmQuit = false
+				return s
+			}},
+			{text: tr("yes"), activate: m.leave},
+		}
+	}
+	items := []menuItem{
+		{text: tr("resume"), activate: func() scene { return s.resume(false) }},
+	}
+	// replays and network matches cannot start over
+	if !m.replaying && m.session == nil {
+		items = append(items, menuItem{
+			text:     tr("restart"),
+			activate: func() scene { return s.resume(true) },
+		})
+	}
+	return append(items,
+		menuItem{text: tr("settings"), activate: func() scene {
+			options := newOptionsScene(m.app, s)
+			options.match = m
+			return options
+		}},
+		menuItem{text: tr("quit"), activate: func() scene {
+			s.confirmQuit = true
+			s.quitMenu = menu{}
+			return s
+		}},
+	)
+}
+
+// currentMenu is the pause menu or the menu asking to confirm quitting.
+func (s *pauseScene) currentMenu() *menu {
+	if s.confirmQuit {
+		return &s.quitMenu
+	}
+	return &s.menu
+}
+
+func (s *pauseScene) update(window draw.Window, in menuInput) scene {
+	m := s.match
+	if in.back || in.pause {
+		if !s.confirmQuit {
+			return s.resume(false)
+		}
+		s.confirmQuit = false
+	}
+	items := s.items()
+	if i := s.currentMenu().update(window, in, items); i != -1 {
+		if next := items[i].activate(); next != s {
+			return next
+		}
+	}
+
+	m.step(window)
+	m.draw(window)
+	fieldW, _ := window.Size()
+	window.FillRect(0, 0, fieldW, fieldH, draw.RGBA(0, 0, 0, 0.5))
+
+	// the items change when quitting needs to be confirmed
+	items = s.items()
+	title := tr("paused")
+	if s.confirmQuit {
+		title = tr("quitConfirm")
+	}
+	const titleScale, itemH = 3, 42
+	titleW, titleH := window.GetScaledTextSize(title, titleScale)
+	w := 500
+	if titleW+80 > w {
+		w = titleW + 80
+	}
+	h := 20 + titleH + 30 + len(items)*itemH + 10
+	x, y := (fieldW-w)/2, (fieldH-h)/2
+	window.FillRect(x, y, w, h, draw.RGBA(1, 1, 1, 0.85))
+	window.DrawRect(x, y, w, h, draw.Black)
+	window.DrawScaledText(title, (fieldW-titleW)/2, y+20, titleScale, draw.Black)
+	s.currentMenu().draw(window, items, x+40, y+20+titleH+30)
+	return s
+}
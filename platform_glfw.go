@@ -0,0 +1,27 @@
+//go:build !windows && (glfw || !sdl2) && !headless
+
+package main
+
+import (
+	"image"
+
+	"github.com/gonutz/glfw/v3.3/glfw"
+)
+
+// screenWidth returns the width of the primary monitor in pixels.
+func screenWidth() int {
+	// draw.RunWindow initializes glfw again, which does nothing if it is
+	// already initialized
+	if err := glfw.Init(); err != nil {
+		return defaultScreenW
+	}
+	return glfw.GetPrimaryMonitor().GetVideoMode().Width
+}
+
+func setWindowIcon() {
+	icon, err := loadIcon()
+	window := glfw.GetCurrentContext()
+	if err == nil && window != nil {
+		window.SetIcon([]image.Image{icon})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build headless && !windows
+
+package main
+
+// screenWidth returns the default width, headless builds do not have the
+// library that knows the screen.
+func screenWidth() int {
+	return defaultScreenW
+}
+
+func setWindowIcon() {}
@@ -0,0 +1,55 @@
+//go:build !windows && sdl2 && !glfw && !headless
+
+package main
+
+import (
+	"image"
+	"image/draw"
+	"unsafe"
+
+	"github.com/gonutz/go-sdl2/sdl"
+)
+
+// screenWidth returns the width of the first display in pixels.
+func screenWidth() int {
+	// draw.RunWindow initializes SDL again, SDL counts the initializations
+	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
+		return defaultScreenW
+	}
+	mode, err := sdl.GetDesktopDisplayMode(0)
+	if err != nil {
+		return defaultScreenW
+	}
+	return int(mode.W)
+}
+
+func setWindowIcon() {
+	icon, err := loadIcon()
+	if err != nil {
+		return
+	}
+	// the game only ever has one window, which is the first one SDL creates
+	window := sdl.GetKeyboardFocus()
+	if window == nil {
+		window, err = sdl.GetWindowFromID(1)
+		if err != nil {
+			return
+		}
+	}
+	b := icon.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), icon, b.Min, draw.Src)
+	surface, err := sdl.CreateRGBSurfaceWithFormatFrom(
+		unsafe.Pointer(&rgba.Pix[0]),
+		int32(b.Dx()),
+		int32(b.Dy()),
+		32,
+		int32(rgba.Stride),
+		sdl.PIXELFORMAT_RGBA32,
+	)
+	if err != nil {
+		return
+	}
+	defer surface.Free()
+	window.SetIcon(surface)
+}
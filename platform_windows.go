@@ -0,0 +1,46 @@
+package main
+
+import "github.com/gonutz/w32"
+
+// screenWidth returns the width of the desktop in pixels.
+func screenWidth() int {
+	r := w32.GetWindowRect(w32.GetDesktopWindow())
+	return int(r.Right - r.Left)
+}
+
+func setWindowIcon() {
+	// the icon is contained in the .exe file as a resource, load it and set it
+	// as the window icon so it appears in the top-left corner of the window and
+	// when you alt+tab between windows
+	const iconResourceID = 10
+	iconHandle := w32.LoadImage(
+		w32.GetModuleHandle(""),
+		w32.MakeIntResource(iconResourceID),
+		w32.IMAGE_ICON,
+		0,
+		0,
+		w32.LR_DEFAULTSIZE|w32.LR_SHARED,
+	)
+	if iconHandle != 0 {
+		window := w32.GetActiveWindow()
+		w32.SendMessage(window, w32.WM_SETICON, w32.ICON_SMALL, uintptr(iconHandle))
+		w32.SendMessage(window, w32.WM_SETICON, w32.ICON_SMALL2, uintptr(iconHandle))
+		w32.SendMessage(window, w32.WM_SETICON, w32.ICON_BIG, uintptr(iconHandle))
+	}
+}
+
+// systemLanguage returns the language code of the user's locale.
+func systemLanguage() string {
+	// the primary language is in the lower 10 bits of the locale ID
+	const (
+		langEnglish = 0x09
+		langGerman  = 0x07
+	)
+	switch w32.GetUserDefaultLCID() & 0x3FF {
+	case langGerman:
+		return "de"
+	case langEnglish:
+		return "en"
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+// Package replay reads and writes recorded matches. A replay file contains the
+// match seed, field width and config followed by the inputs of every frame,
+// which is all that is needed to play the match again exactly as it happened.
+//
+// The file starts with the 4 bytes "KIWI" and a version byte, followed by the
+// seed as a little endian int64, the width as a little endian int32, the length
+// of the JSON encoded game.Config as a little endian uint32, the config itself
+// and then a little endian uint16 per frame with these bits set:
+//
+//	0: player 0 kick    3: player 1 kick    6: player 2 kick    9: player 3 kick
+//	1: player 0 left    4: player 1 left    7: player 2 left   10: player 3 left
+//	2: player 0 right   5: player 1 right   8: player 2 right  11: player 3 right
+//	12: restart
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gonutz/jolina/game"
+)
+
+const (
+	magic   = "KIWI"
+	version = 1
+)
+
+// Header describes the match in a replay.
+type Header struct {
+	Seed   int64
+	Width  int
+	Config game.Config
+}
+
+// Recorder writes a replay frame by frame.
+type Recorder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewRecorder writes the header to w and returns a Recorder for the frames.
+// Call Flush when done recording.
+func NewRecorder(w io.Writer, h Header) (*Recorder, error) {
+	config, err := json.Marshal(h.Config)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{w: bufio.NewWriter(w)}
+	r.w.WriteString(magic)
+	r.w.WriteByte(version)
+	binary.Write(r.w, binary.LittleEndian, h.Seed)
+	binary.Write(r.w, binary.LittleEndian, int32(h.Width))
+	binary.Write(r.w, binary.LittleEndian, uint32(len(config)))
+	r.w.Write(config)
+	if err := r.w.Flush(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record appends the inputs of a frame. Errors are remembered and returned
+// from Flush.
+func (r *Recorder) Record(in game.Inputs) {
+	if r.err == nil {
+		r.err = binary.Write(r.w, binary.LittleEndian, encode(in))
+	}
+}
+
+// Flush writes all buffered frames and returns the first error that happened
+// while recording.
+func (r *Recorder) Flush() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.w.Flush()
+}
+
+// Replay is a fully loaded replay file.
+type Replay struct {
+	Header
+	Frames []game.Inputs
+}
+
+// Load reads a complete replay.
+func Load(r io.Reader) (*Replay, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	const headerSize = len(magic) + 1 + 8 + 4 + 4
+	if len(data) < headerSize || string(data[:len(magic)]) != magic {
+		return nil, errors.New("replay: not a replay file")
+	}
+	if v := data[len(magic)]; v != version {
+		return nil, fmt.Errorf("replay: unsupported version %d", v)
+	}
+	var rep Replay
+	rep.Seed = int64(binary.LittleEndian.Uint64(data[5:]))
+	rep.Width = int(int32(binary.LittleEndian.Uint32(data[13:])))
+	// the size is compared before converting it, an int cannot hold every
+	// uint32 on 32 bit systems
+	configSize := binary.LittleEndian.Uint32(data[17:])
+	if int64(configSize) > int64(len(data)-headerSize) {
+		return nil, errors.New("replay: file is too short")
+	}
+	config := data[headerSize : headerSize+int(configSize)]
+	rep.Config, err = game.LoadConfig(bytes.NewReader(config))
+	if err != nil {
+		return nil, errors.New("replay: invalid config: " + err.Error())
+	}
+	frames := data[headerSize+len(config):]
+	for i := 0; i+1 < len(frames); i += 2 {
+		rep.Frames = append(rep.Frames, decode(binary.LittleEndian.Uint16(frames[i:])))
+	}
+	return &rep, nil
+}
+
+// NewMatch creates the match that was recorded.
+func (r *Replay) NewMatch() *game.Match {
+	return game.NewMatch(r.Width, r.Seed, r.Config)
+}
+
+// restartBit is the bit for Inputs.Restart in a frame.
+const restartBit = 3 * game.MaxPlayers
+
+func encode(in game.Inputs) uint16 {
+	var b uint16
+	bit := func(i int, set bool) {
+		if set {
+			b |= 1 << i
+		}
+	}
+	for i, p := range in.Players {
+		bit(3*i, p.Shoot)
+		bit(3*i+1, p.Left)
+		bit(3*i+2, p.Right)
+	}
+	bit(restartBit, in.Restart)
+	return b
+}
+
+func decode(b uint16) game.Inputs {
+	bit := func(i int) bool {
+		return b&(1<<i) != 0
+	}
+	var in game.Inputs
+	for i := range in.Players {
+		in.Players[i].Shoot = bit(3 * i)
+		in.Players[i].Left = bit(3*i + 1)
+		in.Players[i].Right = bit(3*i + 2)
+	}
+	in.Restart = bit(restartBit)
+	return in
+}
@@ -0,0 +1,73 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gonutz/jolina/game"
+)
+
+func TestRoundTrip(t *testing.T) {
+	h := Header{Seed: 42, Width: 1600, Config: game.DefaultConfig()}
+	var frames []game.Inputs
+	for i := 0; i < 100; i++ {
+		var in game.Inputs
+		in.Players[i%game.MaxPlayers] = game.PlayerInput{Shoot: i%3 == 0, Left: i%5 == 0, Right: i%7 == 0}
+		in.Restart = i == 50
+		frames = append(frames, in)
+	}
+	rep := load(t, record(t, h, frames))
+	if rep.Header != h {
+		t.Errorf("header is %+v, want %+v", rep.Header, h)
+	}
+	if len(rep.Frames) != len(frames) {
+		t.Fatalf("%d frames, want %d", len(rep.Frames), len(frames))
+	}
+	for i := range frames {
+		if rep.Frames[i] != frames[i] {
+			t.Errorf("frame %d is %+v, want %+v", i, rep.Frames[i], frames[i])
+		}
+	}
+}
+
+func record(t *testing.T, h Header, frames []game.Inputs) []byte {
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range frames {
+		r.Record(in)
+	}
+	if err := r.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func load(t *testing.T, data []byte) *Replay {
+	rep, err := Load(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func TestOtherVersionsAreRejected(t *testing.T) {
+	data := record(t, Header{Width: 1600, Config: game.DefaultConfig()}, nil)
+	data[len(magic)] = version + 1
+	if _, err := Load(bytes.NewReader(data)); err == nil {
+		t.Fatal("a replay of another version was loaded")
+	}
+}
+
+func TestConfigSizeLongerThanFile(t *testing.T) {
+	data := record(t, Header{Width: 1600, Config: game.DefaultConfig()}, nil)
+	for _, size := range []uint32{uint32(len(data)), 1<<31 + 1, 1<<32 - 1} {
+		binary.LittleEndian.PutUint32(data[17:], size)
+		if _, err := Load(bytes.NewReader(data)); err == nil {
+			t.Errorf("a config size of %d was loaded from %d bytes", size, len(data))
+		}
+	}
+}
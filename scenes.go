@@ -0,0 +1,698 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/gonutz/jolina/audio"
+	"github.com/gonutz/jolina/draw"
+	"github.com/gonutz/jolina/game"
+	"github.com/gonutz/jolina/netplay"
+	"github.com/gonutz/jolina/pad"
+	"github.com/gonutz/jolina/replay"
+)
+
+// scene is one screen of the game, e.g. the title screen or a running match.
+type scene interface {
+	// update handles the input of one frame and draws the scene. It returns
+	// the scene for the next frame, which is the scene itself to stay and nil
+	// to close the window.
+	update(window draw.Window, in menuInput) scene
+}
+
+// app is the state that is shared by all scenes.
+type app struct {
+	settings settings
+	keys     keyBindings
+	// width is the width of the field for new matches
+	width      int
+	fullscreen bool
+	seed       int64
+	recordPath string
+	difficulty game.Difficulty
+	aiSide     game.Side
+	// headless apps draw into an offscreen.Window, they do not use the game
+	// pads, the audio or the window icon
+	headless bool
+
+	inited    bool
+	pads      pad.Pads
+	padStates []pad.State
+	prevPads  []pad.State
+	// audio plays the music and sounds, if it could not be opened the
+	// sounds are played by the window, without music
+	audio       *audio.Player
+	musicPaused bool
+	scene       scene
+	// match is the running match, it is kept while the results are shown
+	match *matchScene
+	// flashFrames counts down while the window flashes after a screenshot
+	flashFrames int
+	// clock decides how many ticks the game advances in each frame, see
+	// clock.frame, ticks and alpha are its results for the current frame
+	clock clock
+	ticks int
+	alpha float64
+}
+
+func (a *app) update(window draw.Window) {
+	if !a.inited && a.headless {
+		a.inited = true
+	}
+	if !a.inited {
+		setWindowIcon()
+		if a.fullscreen {
+			window.SetFullscreen(true)
+		}
+		if m := a.match; m == nil || !m.replaying {
+			// without game pads the keyboard still works
+			a.pads, _ = pad.Open(game.MaxPlayers)
+		}
+		var err error
+		a.audio, err = audio.Open(draw.OpenFile)
+		if err == nil {
+			err = a.audio.PlayMusic(backMusicPath)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "unable to play music:", err)
+		}
+		a.inited = true
+	}
+	a.updateMusic()
+
+	// a headless app has no real time, it always advances one tick per frame
+	// so it draws the same frames on every computer
+	if a.headless {
+		a.ticks, a.alpha = 1, 1
+	} else {
+		a.ticks, a.alpha = a.clock.frame(time.Now())
+	}
+
+	a.prevPads = a.padStates
+	a.padStates = nil
+	if a.pads != nil {
+		a.padStates = a.pads.Poll()
+	}
+
+	if window.WasKeyPressed(fullscreenKey) {
+		a.setFullscreen(window, !a.fullscreen)
+	}
+
+	fieldW := a.width
+	if a.match != nil {
+		fieldW = a.match.match.Width
+	}
+	cam := newCamera(window, fieldW, fieldH)
+	next := a.scene.update(cam, a.readMenuInput(cam))
+	if next != nil && next != a.scene {
+		// the scene that just returned did not draw anything, let the next
+		// one draw the frame so there is no empty frame in between
+		next = next.update(cam, menuInput{})
+	}
+	if window.WasKeyPressed(screenshotKey) && a.match != nil {
+		if path, err := a.match.saveScreenshot(); err != nil {
+			fmt.Fprintln(os.Stderr, "unable to save the screenshot:", err)
+		} else {
+			fmt.Fprintln(os.Stderr, "saved screenshot", path)
+			a.flashFrames = screenshotFlashFrames
+		}
+	}
+	if a.flashFrames > 0 {
+		alpha := 0.6 * float32(a.flashFrames) / screenshotFlashFrames
+		cam.FillRect(0, 0, fieldW, fieldH, draw.RGBA(1, 1, 1, alpha))
+		a.flashFrames -= a.ticks
+	}
+	cam.drawBars()
+	if next == nil {
+		window.Close()
+	} else {
+		a.scene = next
+	}
+}
+
+// The music gets quieter while the ball is out of play after a goal and at
+// halftime and it fades out while the winner is shown.
+const (
+	musicFadeTime  = 800 * time.Millisecond
+	goalMusicLevel = 0.3
+)
+
+// updateMusic pauses and fades the music depending on the running match and
+// applies the volume settings.
+func (a *app) updateMusic() {
+	if a.audio == nil {
+		return
+	}
+	a.audio.SetMusicVolume(float64(a.settings.MusicVolume) / 100)
+	a.audio.SetEffectsVolume(float64(a.settings.EffectsVolume) / 100)
+	paused := a.match != nil && a.match.paused
+	if paused != a.musicPaused {
+		a.audio.SetMusicPaused(paused)
+		a.musicPaused = paused
+	}
+	level := 1.0
+	if m := a.match; m != nil && m.match.Over() {
+		level = 0
+	} else if m != nil && !m.match.InPlay() {
+		level = goalMusicLevel
+	}
+	a.audio.FadeMusic(level, musicFadeTime)
+	a.audio.Update()
+}
+
+// playSound plays a sound effect with the given volume and pan, see
+// audio.Player.PlaySound. Without audio, the window plays it as it is.
+func (a *app) playSound(window draw.Window, path string, volume, pan float64) {
+	if a.audio != nil {
+		a.audio.PlaySound(path, volume, pan)
+	} else {
+		window.PlaySoundFile(path)
+	}
+}
+
+func (a *app) setFullscreen(window draw.Window, f bool) {
+	a.fullscreen = f
+	window.SetFullscreen(f)
+}
+
+// close ends the running match and releases the game pads, call it after the
+// window was closed.
+func (a *app) close() {
+	a.endMatch()
+	if a.pads != nil {
+		a.pads.Close()
+	}
+	if a.audio != nil {
+		a.audio.Close()
+	}
+}
+
+// setKeys changes and saves the key bindings.
+func (a *app) setKeys(keys keyBindings) {
+	a.keys = keys
+	if err := saveKeyBindings(keyBindingsPath(), keys); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to save the keys:", err)
+	}
+}
+
+// newSeed returns the seed for the next match, either the one given on the
+// command line or a random one.
+func (a *app) newSeed() int64 {
+	if a.seed != 0 {
+		return a.seed
+	}
+	return time.Now().UnixNano()
+}
+
+// mode is the kind of match that is started from the menu.
+type mode int
+
+const (
+	vsAI mode = iota
+	twoPlayers
+	practice
+)
+
+// newMatch starts a match with the current settings.
+func (a *app) newMatch(mode mode) *matchScene {
+	seed := a.newSeed()
+	config := a.settings.Config
+	config.Practice = mode == practice
+	var ais []*game.AI
+	if mode == vsAI {
+		// the computer plays all kiwis of its team
+		for i := 0; i < config.Players; i++ {
+			if game.Team(i) == a.aiSide {
+				ais = append(ais, game.NewAI(i, a.difficulty, seed+int64(i/2)))
+			}
+		}
+	}
+	return a.startMatch(game.NewMatch(a.width, seed, config), ais, nil)
+}
+
+// startMatch makes the given match the running match. If a record file was
+// given on the command line, the match is recorded into it.
+func (a *app) startMatch(match *game.Match, ais []*game.AI, session *netplay.Session) *matchScene {
+	a.endMatch()
+	m := &matchScene{
+		app:     a,
+		match:   match,
+		ais:     ais,
+		session: session,
+	}
+	if a.recordPath != "" {
+		f, err := os.Create(a.recordPath)
+		if err == nil {
+			m.recorder, err = replay.NewRecorder(f, replay.Header{
+				Seed:   match.Seed(),
+				Width:  match.Width,
+				Config: match.Config,
+			})
+			m.recordFile = f
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "unable to record the match:", err)
+		}
+	}
+	a.match = m
+	return m
+}
+
+// endMatch finishes the recording and network connection of the running
+// match, if there is one.
+func (a *app) endMatch() {
+	m := a.match
+	if m == nil {
+		return
+	}
+	a.match = nil
+	if m.recorder != nil {
+		if m.session != nil {
+			// only the inputs that both sides agree on are recorded
+			for _, in := range m.session.ConfirmedInputs() {
+				m.recorder.Record(in)
+			}
+		}
+		if err := m.recorder.Flush(); err != nil {
+			fmt.Fprintln(os.Stderr, "unable to record the match:", err)
+		}
+	}
+	if m.recordFile != nil {
+		m.recordFile.Close()
+	}
+	if m.session != nil {
+		m.session.Close()
+	}
+}
+
+// matchScene is a running match, either between players on this computer,
+// against the computer, in a replay or over the network.
+type matchScene struct {
+	app          *app
+	match        *game.Match
+	ais          []*game.AI
+	session      *netplay.Session
+	replaying    bool
+	replayFrames []game.Inputs
+	recorder     *replay.Recorder
+	recordFile   *os.File
+	rebinding    *rebinder
+	// paused is set while the pause menu is showing, see pauseScene
+	paused bool
+	// restartPending restarts the match in the next tick
+	restartPending bool
+	// prev are the positions before the last tick, see interpolated
+	prev *positions
+	// standalone matches were started from the command line, leaving them
+	// closes the window
+	standalone bool
+}
+
+func (m *matchScene) update(window draw.Window, in menuInput) scene {
+	if m.rebinding != nil {
+		m.updateRebinding(window)
+	} else if window.WasKeyPressed(rebindKey) && !m.replaying {
+		m.rebinding = newRebinder(m.app.keys, m.match.Config.Players)
+	} else if in.back || in.pause {
+		return newPauseScene(m)
+	}
+
+	m.step(window)
+	m.draw(window)
+	if m.match.Over() {
+		return newResultsScene(m)
+	}
+	return m
+}
+
+// leave returns the scene that comes after the match.
+func (m *matchScene) leave() scene {
+	m.app.endMatch()
+	if m.standalone {
+		return nil
+	}
+	return newModeScene(m.app)
+}
+
+func (m *matchScene) updateRebinding(window draw.Window) {
+	if done, ok := m.rebinding.update(window); done {
+		if ok {
+			m.app.setKeys(m.rebinding.keys)
+		}
+		m.rebinding = nil
+	}
+}
+
+// step advances the match by the ticks of this frame, see app.ticks. It
+// returns true if the match was restarted.
+func (m *matchScene) step(window draw.Window) bool {
+	restarted := false
+	for i := 0; i < m.app.ticks; i++ {
+		restarted = m.tick(window) || restarted
+	}
+	return restarted
+}
+
+// tick advances the match by one tick and plays the sounds for what happened.
+// It returns true if the match was restarted.
+func (m *matchScene) tick(window draw.Window) bool {
+	a := m.app
+	var events []game.Event
+	if m.session != nil {
+		// the own kiwi can be controlled with either side's keys, the
+		// network match cannot wait while the keys are changed or while it
+		// is paused
+		var in game.Inputs
+		if m.rebinding == nil && !m.paused {
+			in = readInputs(window, a.keys, a.padStates, 2, nil)
+		}
+		m.prev = positionsOf(m.match)
+		events = m.session.Advance(merged(in), m.restartPending)
+		m.restartPending = false
+		m.match = m.session.Match()
+	} else if m.rebinding == nil && !m.paused && (!m.replaying || len(m.replayFrames) > 0) {
+		// when a replay is over the match stops and keeps showing the last
+		// frame, while the keys are changed the match is paused
+		var in game.Inputs
+		if m.replaying {
+			in = m.replayFrames[0]
+			m.replayFrames = m.replayFrames[1:]
+		} else {
+			in = readInputs(window, a.keys, a.padStates, m.match.Config.Players, m.ais)
+			in.Restart = m.restartPending
+			if m.match.Config.Practice && m.match.Config.Players == 2 {
+				in.Players[0] = merged(in)
+			}
+		}
+		m.restartPending = false
+		for _, ai := range m.ais {
+			in.Players[ai.Player()] = ai.Input(m.match)
+		}
+		if m.recorder != nil {
+			m.recorder.Record(in)
+		}
+		m.prev = positionsOf(m.match)
+		events = m.match.Step(in)
+	} else {
+		// nothing moves, so there is nothing to interpolate
+		m.prev = nil
+	}
+	restarted := false
+	for _, e := range events {
+		a.playEventSound(window, m.match, e)
+		restarted = restarted || e.Type == game.Restart
+	}
+	return restarted
+}
+
+// positions are what moves smoothly in a match. When drawing, they are
+// interpolated between the last two ticks, see interpolated.
+type positions struct {
+	kiwis        [game.MaxPlayers]int
+	ballX, ballY int
+	ballRotation int
+}
+
+func positionsOf(match *game.Match) *positions {
+	p := &positions{
+		ballX:        match.BallX,
+		ballY:        match.BallY,
+		ballRotation: match.BallRotation,
+	}
+	for i := range match.Players {
+		p.kiwis[i] = match.Players[i].X
+	}
+	return p
+}
+
+// interpolated returns the match as it is drawn in this frame, with the kiwis
+// and the ball between where they were in the last two ticks. This makes them
+// move smoothly on monitors that show more frames than there are ticks.
+func (m *matchScene) interpolated() *game.Match {
+	alpha := 1.0
+	if m.app != nil {
+		alpha = m.app.alpha
+	}
+	if m.prev == nil || alpha >= 1 {
+		return m.match
+	}
+	// after a goal or a restart the kiwis and the ball jump to the kick-off,
+	// they do not fly there
+	now := positionsOf(m.match)
+	maxJump := m.match.Width / 8
+	jumped := abs(now.ballX-m.prev.ballX) > maxJump || abs(now.ballY-m.prev.ballY) > maxJump
+	for i := range m.match.Players {
+		jumped = jumped || abs(now.kiwis[i]-m.prev.kiwis[i]) > maxJump
+	}
+	if jumped {
+		return m.match
+	}
+	between := func(from, to int) int {
+		return from + int(math.Round(alpha*float64(to-from)))
+	}
+	match := *m.match
+	match.Players = append([]game.Player(nil), m.match.Players...)
+	for i := range match.Players {
+		match.Players[i].X = between(m.prev.kiwis[i], now.kiwis[i])
+	}
+	match.BallX = between(m.prev.ballX, now.ballX)
+	match.BallY = between(m.prev.ballY, now.ballY)
+	match.BallRotation = between(m.prev.ballRotation, now.ballRotation)
+	return &match
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// merged lets one kiwi be controlled with the controls of the first two
+// kiwis.
+func merged(in game.Inputs) game.PlayerInput {
+	a, b := in.Players[0], in.Players[1]
+	return game.PlayerInput{
+		Shoot: a.Shoot || b.Shoot,
+		Left:  a.Left || b.Left,
+		Right: a.Right || b.Right,
+	}
+}
+
+func (m *matchScene) draw(window draw.Window) {
+	match := m.interpolated()
+	m.drawField(window)
+	drawGoals(window, match)
+	// the blue kiwis are drawn behind the ball and the white kiwis in front of
+	// it
+	kiwis := func(team game.Side) {
+		for i := range match.Players {
+			if game.Team(i) == team && match.OnField(i) {
+				drawKiwi(window, match, i)
+			}
+		}
+	}
+	kiwis(game.LeftSide)
+	// draw ball, when it flies its shadow stays on the ground
+	ballY := fieldH - game.BallH - 10
+	if match.BallY > 0 {
+		window.FillEllipse(match.BallX+5, ballY+game.BallH-8, game.BallW-10, 10, draw.RGBA(0, 0, 0, 0.3))
+	}
+	window.DrawImageFileRotated(ballPath, match.BallX, ballY-match.BallY, match.BallRotation)
+	kiwis(game.RightSide)
+
+	if m.rebinding != nil {
+		m.rebinding.draw(window)
+	}
+}
+
+// drawKiwi draws the kiwi with the given player number with its power meter.
+// The images show the blue kiwi looking to the right and the white kiwi looking
+// to the left, after they changed ends they are mirrored.
+func drawKiwi(window draw.Window, match *game.Match, player int) {
+	p := match.Players[player]
+	team := game.Team(player)
+	path, y := leftKiwiPath, fieldH-game.KiwiH-20
+	if p.ShootFrames > 0 {
+		path = leftKiwiShootPath
+	}
+	if team == game.RightSide {
+		path, y = rightKiwiPath, fieldH-game.KiwiH
+		if p.ShootFrames > 0 {
+			path = rightKiwiShootPath
+		}
+	}
+	path = kiwiImage(path, player)
+	if match.End(team) == team {
+		window.DrawImageFile(path, p.X, y)
+	} else {
+		// a negative source width mirrors the image
+		window.DrawImageFilePart(
+			path,
+			game.KiwiW, 0, -game.KiwiW, game.KiwiH,
+			p.X, y, game.KiwiW, game.KiwiH,
+			0,
+		)
+	}
+	drawCharge(window, match, p, y)
+}
+
+// drawCharge draws a power meter above a kiwi that is charging a kick, y is
+// the top of the kiwi.
+func drawCharge(window draw.Window, match *game.Match, p game.Player, y int) {
+	if p.Charge == 0 || match.Config.ChargeFrames == 0 {
+		return
+	}
+	const w, h = 120, 16
+	x := p.X + (game.KiwiW-w)/2
+	y -= h + 10
+	charge := float32(p.Charge) / float32(match.Config.ChargeFrames)
+	window.FillRect(x, y, w, h, draw.White)
+	// the meter turns from green to red as it fills up
+	window.FillRect(x, y, int(charge*w), h, draw.RGB(charge, 1-charge, 0))
+	window.DrawRect(x, y, w, h, draw.Black)
+}
+
+// groundY is the screen position of the ground that the ball rolls on.
+const groundY = fieldH - 10
+
+// drawGoals draws the nets, the goal frames and marks for the keeper zones.
+func drawGoals(window draw.Window, match *game.Match) {
+	if match.Config.KeeperZone > 0 {
+		for _, side := range []game.Side{game.LeftSide, game.RightSide} {
+			left, right := match.KeeperZone(side)
+			window.FillRect(left, groundY-2, right-left, 4, draw.White)
+		}
+	}
+	if !match.HasGoals() {
+		return
+	}
+	fill := func(r game.Rect) {
+		window.FillRect(r.X, groundY-r.Y-r.H, r.W, r.H, draw.White)
+	}
+	netColor := draw.RGBA(1, 1, 1, 0.5)
+	const mesh = 15
+	for _, side := range []game.Side{game.LeftSide, game.RightSide} {
+		post, crossbar := match.GoalBars(side)
+		netX, netW := 0, crossbar.W-post.W
+		if side == game.RightSide {
+			netX = post.X + post.W
+		}
+		for x := netX; x <= netX+netW; x += mesh {
+			window.DrawLine(x, groundY, x, groundY-crossbar.Y, netColor)
+		}
+		for y := groundY; y >= groundY-crossbar.Y; y -= mesh {
+			window.DrawLine(netX, y, netX+netW, y, netColor)
+		}
+		fill(post)
+		fill(crossbar)
+	}
+}
+
+// drawField draws the grass, the score and the clock and returns the height
+// of the score text.
+func (m *matchScene) drawField(window draw.Window) int {
+	fieldW, _ := window.Size()
+	window.FillRect(0, 0, fieldW, fieldH, draw.LightGreen)
+	const scoreScale = 3
+	score := fmt.Sprintf("%d : %d", m.match.Score[game.LeftSide], m.match.Score[game.RightSide])
+	scoreTextW, scoreTextH := window.GetScaledTextSize(score, scoreScale)
+	window.DrawScaledText(score, (fieldW-scoreTextW)/2, 10, scoreScale, draw.Black)
+	if m.match.Timed() {
+		const clockScale = 2
+		clock := tr("goldenGoal")
+		if !m.match.Overtime {
+			seconds := (m.match.Clock + 59) / 60
+			clock = fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+		}
+		_, clockH := window.GetScaledTextSize(clock, clockScale)
+		x := (fieldW+scoreTextW)/2 + 40
+		window.DrawScaledText(clock, x, 10+(scoreTextH-clockH)/2, clockScale, draw.Black)
+		if m.match.Half == 1 && m.match.Clock == 0 {
+			text := tr("halftime")
+			w, _ := window.GetScaledTextSize(text, clockScale)
+			window.DrawScaledText(text, (fieldW-w)/2, 20+scoreTextH, clockScale, draw.Black)
+		}
+	}
+	return scoreTextH
+}
+
+// resultsScene shows the winner of a match. The match keeps running in the
+// background, so kicking restarts it just like choosing to play again does.
+type resultsScene struct {
+	match      *matchScene
+	menu       menu
+	blinkTimer int
+	blinking   bool
+}
+
+func newResultsScene(m *matchScene) *resultsScene {
+	return &resultsScene{match: m}
+}
+
+func (s *resultsScene) items() []menuItem {
+	again := menuItem{text: tr("again"), activate: func() scene {
+		s.match.restartPending = true
+		return s
+	}}
+	if s.match.standalone {
+		return []menuItem{again, {text: tr("quit"), activate: s.match.leave}}
+	}
+	return []menuItem{again, {text: tr("mainMenu"), activate: s.match.leave}}
+}
+
+func (s *resultsScene) update(window draw.Window, in menuInput) scene {
+	m := s.match
+	if m.rebinding != nil {
+		m.updateRebinding(window)
+	} else if in.back {
+		return m.leave()
+	}
+
+	items := s.items()
+	canRestart := m.match.CanRestart() && m.rebinding == nil && !m.replaying
+	if canRestart {
+		if i := s.menu.update(window, in, items); i != -1 {
+			if next := items[i].activate(); next != s {
+				return next
+			}
+		}
+	}
+	if m.step(window) {
+		m.draw(window)
+		return m
+	}
+
+	scoreTextH := m.drawField(window)
+	winner := leftKiwiPath
+	if m.match.RightWon {
+		winner = rightKiwiPath
+	}
+	fieldW, _ := window.Size()
+	window.DrawImageFile(winner, (fieldW-game.KiwiW)/2, scoreTextH+(fieldH-scoreTextH-game.KiwiH)/2)
+	if m.match.CanRestart() {
+		s.blinkTimer -= m.app.ticks
+		if s.blinkTimer < 0 {
+			s.blinkTimer = m.app.settings.BlinkCooldown
+			s.blinking = !s.blinking
+		}
+		if s.blinking {
+			window.DrawScaledText(
+				tr("restartHint"),
+				10,
+				fieldH-scoreTextH,
+				2,
+				draw.Black,
+			)
+		}
+		if canRestart {
+			s.menu.draw(window, items, 20, 20)
+		}
+	}
+	if m.rebinding != nil {
+		m.rebinding.draw(window)
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gonutz/jolina/draw"
+	"github.com/gonutz/jolina/game"
+	"github.com/gonutz/jolina/offscreen"
+	"github.com/gonutz/jolina/offscreen/golden"
+)
+
+// headlessGame runs the game in an offscreen window at half the size of the
+// field. The match is started right away, without the menus.
+type headlessGame struct {
+	app    *app
+	match  *matchScene
+	window *offscreen.Window
+}
+
+func newHeadlessGame(t *testing.T) *headlessGame {
+	draw.OpenFile = openFile
+	if err := setLanguage("en"); err != nil {
+		t.Fatal(err)
+	}
+	a := &app{
+		settings: defaultSettings(),
+		keys:     defaultKeyBindings(),
+		width:    defaultFieldW,
+		seed:     1,
+		aiSide:   game.RightSide,
+		headless: true,
+	}
+	m := a.startMatch(game.NewMatch(a.width, a.seed, a.settings.Config), nil, nil)
+	a.scene = m
+	return &headlessGame{
+		app:    a,
+		match:  m,
+		window: offscreen.New(defaultFieldW/2, fieldH/2),
+	}
+}
+
+// frames runs n frames of the game.
+func (g *headlessGame) frames(n int) {
+	for i := 0; i < n; i++ {
+		g.window.Frame(g.app.update)
+	}
+}
+
+// score lets the blue team score a goal into the right goal.
+func (g *headlessGame) score(t *testing.T) {
+	m := g.match.match
+	before := m.Score[game.LeftSide]
+	// the ball starts behind the white kiwi, its legs would block it
+	m.BallX, m.BallY, m.BallVx, m.BallVy = m.Width-m.Config.GoalWidth-5, 0, 10, 0
+	for i := 0; i < 60 && m.Score[game.LeftSide] == before; i++ {
+		g.frames(1)
+	}
+	if m.Score[game.LeftSide] == before {
+		t.Fatal("the blue team did not score")
+	}
+}
+
+func TestMatchScreen(t *testing.T) {
+	g := newHeadlessGame(t)
+	keys := g.app.keys
+	// the blue kiwi walks and the white kiwi charges a kick
+	g.window.SetKeyDown(keys[0].Right, true)
+	g.window.SetKeyDown(keys[1].Kick, true)
+	g.frames(20)
+	golden.Check(t, "match", g.window.Image())
+}
+
+func TestGoalScreen(t *testing.T) {
+	g := newHeadlessGame(t)
+	g.score(t)
+	g.frames(5)
+	golden.Check(t, "goal", g.window.Image())
+}
+
+func TestWinScreen(t *testing.T) {
+	g := newHeadlessGame(t)
+	g.match.match.Score[game.LeftSide] = g.app.settings.WinScore - 1
+	g.score(t)
+	for i := 0; i < 10*60 && !g.match.match.CanRestart(); i++ {
+		g.frames(1)
+	}
+	if _, ok := g.app.scene.(*resultsScene); !ok || !g.match.match.LeftWon {
+		t.Fatal("the results are not shown after the winning goal")
+	}
+	// the restart hint blinks, it is shown in the first frame after the
+	// restart delay
+	g.frames(1)
+	golden.Check(t, "win", g.window.Image())
+}
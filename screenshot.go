@@ -0,0 +1,73 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gonutz/jolina/draw"
+	"github.com/gonutz/jolina/offscreen"
+)
+
+// screenshotFlashFrames is how long the window flashes white after a
+// screenshot was saved.
+const screenshotFlashFrames = 12
+
+// screenshot draws the match into an image at the logical size of the field,
+// independent of the window size. It is drawn the same way as in the window,
+// only the menus over the match are left out.
+func (m *matchScene) screenshot() *image.RGBA {
+	window := offscreen.New(m.match.Width, fieldH)
+	window.Frame(func(window draw.Window) {
+		cam := newCamera(window, m.match.Width, fieldH)
+		m.draw(cam)
+		cam.drawBars()
+	})
+	return window.Image()
+}
+
+// saveScreenshot saves a picture of the match in the user's pictures
+// directory and returns its path.
+func (m *matchScene) saveScreenshot() (string, error) {
+	dir, err := picturesDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := "kiwi_kick_" + time.Now().Format("2006-01-02_15-04-05.000") + ".png"
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	err = png.Encode(f, m.screenshot())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return path, err
+}
+
+// picturesDir returns the user's pictures directory. On Linux it is asked
+// from xdg-user-dir, which may be a translated name, e.g. ~/Bilder.
+func picturesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" {
+		// without a pictures directory, xdg-user-dir returns the home
+		// directory
+		out, err := exec.Command("xdg-user-dir", "PICTURES").Output()
+		if dir := strings.TrimSpace(string(out)); err == nil && dir != "" && dir != home {
+			return dir, nil
+		}
+	}
+	return filepath.Join(home, "Pictures"), nil
+}
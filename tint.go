@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// teammateShade darkens the images of the second kiwi in each team so the
+// teammates can be told apart.
+const teammateShade = 0.7
+
+// tintedImages are the generated images by their made up paths, see
+// openFile.
+var tintedImages = map[string][]byte{}
+
+// openFile replaces draw.OpenFile, it reads the embedded resources and the
+// tinted images.
+func openFile(path string) (io.ReadCloser, error) {
+	if data, ok := tintedImages[path]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return rsc.Open(path)
+}
+
+// kiwiImage returns the path of the image for the kiwi with the given player
+// number. The first kiwi of each team uses the original image, the second one
+// a darker copy which is created the first time that it is needed.
+func kiwiImage(path string, player int) string {
+	if player < 2 {
+		return path
+	}
+	tinted := strings.TrimSuffix(path, ".png") + "_2.png"
+	if _, ok := tintedImages[tinted]; ok {
+		return tinted
+	}
+	data, err := shade(path, teammateShade)
+	if err != nil {
+		return path
+	}
+	tintedImages[tinted] = data
+	return tinted
+}
+
+// shade multiplies the colors of a PNG image by the given factor and keeps
+// its transparency. It returns the new image encoded as a PNG.
+func shade(path string, factor float64) ([]byte, error) {
+	f, err := rsc.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			c.R = uint8(float64(c.R) * factor)
+			c.G = uint8(float64(c.G) * factor)
+			c.B = uint8(float64(c.B) * factor)
+			out.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}